@@ -1,14 +1,191 @@
 package assets
 
 import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	Bg          = lipgloss.Color("#000000")
-	Purple      = lipgloss.Color("#7168f2")
-	Orange      = lipgloss.Color("#db9a3d")
-	Input_wrong = lipgloss.Color("#573c17")
-	Cyan        = lipgloss.Color("#03fcc6")
-	Green       = lipgloss.Color("#03fc56")
-)
+// Theme is a colour scheme, each colour named for what it is used for
+type Theme struct {
+	Primary    lipgloss.TerminalColor // borders, titles and the logo
+	Secondary  lipgloss.TerminalColor // menu items and the word being asked
+	Accent     lipgloss.TerminalColor // headings, the answer input, hints and notices
+	Correct    lipgloss.TerminalColor // correct answers and goals reached
+	Wrong      lipgloss.TerminalColor // wrong answers
+	Muted      lipgloss.TerminalColor // help text
+	Background lipgloss.TerminalColor // behind lessons, NoColor for the terminal's own
+}
+
+// NoColorTheme is used when colours are turned off with NO_COLOR or -no-color
+const NoColorTheme = "no-color"
+
+// Themes built in to lomo
+var Themes = map[string]Theme{
+	"default": {
+		Primary:    lipgloss.Color("#7168f2"),
+		Secondary:  lipgloss.Color("#03fcc6"),
+		Accent:     lipgloss.Color("#db9a3d"),
+		Correct:    lipgloss.Color("#03fc56"),
+		Wrong:      lipgloss.Color("#573c17"),
+		Muted:      lipgloss.Color("#626262"),
+		Background: lipgloss.Color("#000000"),
+	},
+	// For terminals with a light background
+	"light": {
+		Primary:    lipgloss.Color("#4b3fd1"),
+		Secondary:  lipgloss.Color("#00796b"),
+		Accent:     lipgloss.Color("#a35c00"),
+		Correct:    lipgloss.Color("#1b7f2a"),
+		Wrong:      lipgloss.Color("#b3261e"),
+		Muted:      lipgloss.Color("#767676"),
+		Background: lipgloss.NoColor{},
+	},
+	"solarized": {
+		Primary:    lipgloss.Color("#6c71c4"),
+		Secondary:  lipgloss.Color("#2aa198"),
+		Accent:     lipgloss.Color("#b58900"),
+		Correct:    lipgloss.Color("#859900"),
+		Wrong:      lipgloss.Color("#dc322f"),
+		Muted:      lipgloss.Color("#586e75"),
+		Background: lipgloss.Color("#002b36"),
+	},
+	// Bright colours on black, for low vision or washed out screens
+	"high-contrast": {
+		Primary:    lipgloss.Color("#ffffff"),
+		Secondary:  lipgloss.Color("#ffff00"),
+		Accent:     lipgloss.Color("#00ffff"),
+		Correct:    lipgloss.Color("#00ff00"),
+		Wrong:      lipgloss.Color("#ff5f5f"),
+		Muted:      lipgloss.Color("#d0d0d0"),
+		Background: lipgloss.Color("#000000"),
+	},
+	// The terminal's own colours, states are shown with text and symbols alone
+	NoColorTheme: {
+		Primary:    lipgloss.NoColor{},
+		Secondary:  lipgloss.NoColor{},
+		Accent:     lipgloss.NoColor{},
+		Correct:    lipgloss.NoColor{},
+		Wrong:      lipgloss.NoColor{},
+		Muted:      lipgloss.NoColor{},
+		Background: lipgloss.NoColor{},
+	},
+	"gruvbox": {
+		Primary:    lipgloss.Color("#d3869b"),
+		Secondary:  lipgloss.Color("#8ec07c"),
+		Accent:     lipgloss.Color("#fe8019"),
+		Correct:    lipgloss.Color("#b8bb26"),
+		Wrong:      lipgloss.Color("#fb4934"),
+		Muted:      lipgloss.Color("#928374"),
+		Background: lipgloss.Color("#282828"),
+	},
+}
+
+// Active is the theme views are drawn with
+var Active = Themes["default"]
+
+// ThemeNames lists the built in themes
+func ThemeNames() []string {
+	return slices.Sorted(maps.Keys(Themes))
+}
+
+// UseTheme makes the theme called name active. Custom themes are colours by role, e.g.
+// "primary": "#ff00ff", with "base" naming the theme their other roles come from.
+func UseTheme(name string, custom map[string]map[string]string) error {
+	theme, err := resolveTheme(name, custom)
+	if err != nil {
+		return err
+	}
+	Active = theme
+	return nil
+}
+
+// UseNoColor switches to the built in no colour theme, which unlike a configured theme can't fail to load
+func UseNoColor() {
+	Active = Themes[NoColorTheme]
+}
+
+// CheckTheme reports whether UseTheme would succeed, without changing the active theme
+func CheckTheme(name string, custom map[string]map[string]string) error {
+	_, err := resolveTheme(name, custom)
+	return err
+}
+
+// resolveTheme builds the theme called name, checking every custom theme along the way
+func resolveTheme(name string, custom map[string]map[string]string) (Theme, error) {
+	for _, other := range slices.Sorted(maps.Keys(custom)) {
+		if _, err := buildTheme(other, custom, nil); err != nil {
+			return Theme{}, err
+		}
+	}
+	return buildTheme(name, custom, nil)
+}
+
+func buildTheme(name string, custom map[string]map[string]string, seen []string) (Theme, error) {
+	colours, ok := custom[name]
+	if !ok {
+		theme, ok := Themes[name]
+		if !ok {
+			names := append(ThemeNames(), slices.Sorted(maps.Keys(custom))...)
+			return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+		return theme, nil
+	}
+	if slices.Contains(seen, name) {
+		return Theme{}, fmt.Errorf("based on itself")
+	}
+
+	base := "default"
+	if colours["base"] != "" {
+		base = colours["base"]
+	}
+	theme, err := buildTheme(base, custom, append(seen, name))
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+	roles := map[string]*lipgloss.TerminalColor{
+		"primary":    &theme.Primary,
+		"secondary":  &theme.Secondary,
+		"accent":     &theme.Accent,
+		"correct":    &theme.Correct,
+		"wrong":      &theme.Wrong,
+		"muted":      &theme.Muted,
+		"background": &theme.Background,
+	}
+	for role, value := range colours {
+		if role == "base" {
+			continue
+		}
+		field, ok := roles[role]
+		if !ok {
+			return Theme{}, fmt.Errorf("theme %s: unknown colour %q, expected base or one of %s", name, role, strings.Join(slices.Sorted(maps.Keys(roles)), ", "))
+		}
+		colour, err := parseColour(value)
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %s: %w", name, role, err)
+		}
+		*field = colour
+	}
+	return theme, nil
+}
+
+var hexColour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseColour accepts "#rgb", "#rrggbb", an ANSI colour number or "none" for the terminal's own colour
+func parseColour(value string) (lipgloss.TerminalColor, error) {
+	if value == "none" {
+		return lipgloss.NoColor{}, nil
+	}
+	if hexColour.MatchString(value) {
+		return lipgloss.Color(value), nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(value), nil
+	}
+	return nil, fmt.Errorf("invalid colour %q, expected #rrggbb, an ANSI colour number or none", value)
+}
@@ -1,76 +1,157 @@
-package main
+package bootstrap
 
 import (
 	"bufio"
 	"database/sql"
-	"encoding/xml"
+	"flag"
 	"fmt"
-
+	"io"
 	"os"
 	"strings"
 
+	"github.com/decarlec/lomo/config"
 	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/pack"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-type XmlDictionary struct {
-	Letters []XmlLetter `xml:"l"`
-}
-
-type XmlLetter struct {
-	Words []XmlWord `xml:"w"`
-}
-
 type XmlWord struct {
 	Spanish string `xml:"c"`
 	English string `xml:"d"`
 	Type    string `xml:"t"`
 }
 
-func main() {
-	askForConfirmation("Deleting words.db. Are you sure?")
-	err := os.Remove("../db/words.db")
-	if err != nil && !os.IsNotExist(err) {
-		fmt.Printf("Failed to delete existing database: %v\n", err)
-		os.Exit(1)
-	}
+// Config controls a bootstrap run
+type Config struct {
+	Words      string    // frequency list, a tsv of Number, Spanish, in English
+	Dictionary string    // xml dictionary
+	Schema     string    // schema file, the built in pack schema when empty
+	Out        string    // database to create
+	LessonSize int       // words per lesson
+	Limit      int       // only use the most frequent Limit words, 0 for all of them
+	Yes        bool      // don't ask before replacing Out
+	In         io.Reader // where confirmation answers are read from
+}
 
-	// Initialize SQLite3 database
-	db, err := sql.Open("sqlite3", "../db/words.db")
+const usage = `usage: lomo bootstrap [flags]
+
+Builds a words database from a frequency list and an xml dictionary.
+
+flags:
+`
+
+// Command runs `lomo bootstrap`
+func Command(args []string) error {
+	settings, err := config.Load()
 	if err != nil {
-		fmt.Printf("Failed to open database: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-	defer db.Close()
+	cfg := Config{In: os.Stdin}
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.Words, "words", "bootstrap/1000words.tsv", "frequency list tsv")
+	fs.StringVar(&cfg.Dictionary, "dict", "bootstrap/es-en.xml", "xml dictionary")
+	fs.StringVar(&cfg.Schema, "schema", "", "schema sql file (default the built in pack schema)")
+	fs.StringVar(&cfg.Out, "out", "db/words.db", "database to create")
+	fs.IntVar(&cfg.LessonSize, "lesson-size", settings.LessonSize, "words per lesson (default from lesson_size in the config file)")
+	fs.IntVar(&cfg.Limit, "limit", 0, "only use the most frequent `n` words (0 for all)")
+	fs.BoolVar(&cfg.Yes, "yes", false, "replace the output database without asking")
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return Run(cfg)
+}
 
-	// Init databse schema
-	sqlFile, err := os.ReadFile("schema.sql")
-	if err != nil {
-		fmt.Printf("Failed to read schema file: %v", err)
+// Run builds the words database described by cfg.
+// The database is built next to Out and only moved into place once every step has succeeded.
+func Run(cfg Config) error {
+	if cfg.LessonSize <= 0 {
+		return fmt.Errorf("lesson size must be positive, got %d", cfg.LessonSize)
+	}
+	if cfg.Limit < 0 {
+		return fmt.Errorf("limit must not be negative, got %d", cfg.Limit)
+	}
+
+	schema := pack.Schema
+	if cfg.Schema != "" {
+		sqlFile, err := os.ReadFile(cfg.Schema)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+		schema = string(sqlFile)
 	}
 
-	_, err = db.Exec(string(sqlFile))
+	words, err := parseLessonWords(cfg.Words)
 	if err != nil {
-		fmt.Printf("Failed to execute schema: %v", err)
+		return err
+	}
+	if cfg.Limit > 0 && cfg.Limit < len(words) {
+		words = words[:cfg.Limit]
 	}
 
-	//Create default user
-	createUser(db)
+	if _, err := os.Stat(cfg.Out); err == nil && !cfg.Yes {
+		ok, err := askForConfirmation(cfg.In, fmt.Sprintf("Replacing %s. Are you sure?", cfg.Out))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted, %s was left untouched", cfg.Out)
+		}
+	}
 
-	words := parseLessonWords("1000words.tsv")
-	//Processes words from both files
-	processWords(db, words)
+	tmpPath := cfg.Out + ".tmp"
+	err = os.Remove(tmpPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete old temporary database: %w", err)
+	}
+	defer os.Remove(tmpPath)
 
-	//Create all lessons
-	createLessons(db, words, 30)
+	// Initialize SQLite3 database
+	db, err := sql.Open("sqlite3", tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
 
-	//Delete words with no lessons
-	deleteOrphanWords(db)
-}
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"Creating schema", func() error {
+			_, err := db.Exec(schema)
+			return err
+		}},
+		//Processes words from both files
+		{"Importing dictionary", func() error { return processWords(db, cfg.Dictionary, words) }},
+		//Create all lessons
+		{"Creating lessons", func() error { return createLessons(db, words, cfg.LessonSize) }},
+		//Delete words with no lessons
+		{"Deleting orphan words", func() error { return deleteOrphanWords(db) }},
+	}
+	for i, step := range steps {
+		fmt.Printf("[%d/%d] %s\n", i+1, len(steps), step.name)
+		if err := step.run(); err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(step.name), err)
+		}
+	}
 
-func createUser(db *sql.DB) error {
-	_, err := db.Exec("insert into users (name) values (?)", "default")
-	return err
+	if err := db.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, cfg.Out); err != nil {
+		return fmt.Errorf("failed to move database into place: %w", err)
+	}
+	fmt.Printf("Wrote %s\n", cfg.Out)
+	return nil
 }
 
 // Delete words that have no primary translation 
@@ -79,85 +160,67 @@ func deleteOrphanWords(db *sql.DB) error {
 	return err
 }
 
-// Inserts database words.
-func processWords(db *sql.DB, lessonWords []XmlWord) error {
-	dat, err := os.ReadFile("es-en.xml")
-	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
-		os.Exit(1)
+// Inserts database words for every entry in the frequency list, with translations from the dictionary.
+// The dictionary is streamed, so only words in the frequency list are ever held in memory.
+func processWords(db *sql.DB, dictPath string, lessonWords []XmlWord) error {
+	// Primary translation for each word in the frequency list
+	primary := make(map[string]string, len(lessonWords))
+	for _, word := range lessonWords {
+		if _, exists := primary[word.Spanish]; !exists {
+			primary[word.Spanish] = word.English
+		}
 	}
 
-	var xDict XmlDictionary
-
-	if err := xml.Unmarshal([]byte(dat), &xDict); err != nil {
-		panic(err)
+	processedWords := make(map[string]models.Word, len(lessonWords))
+	entries := 0
+	// Gather translations, words can have several entries spread through the dictionary
+	err := readDictionary(dictPath, func(xWord XmlWord) error {
+		entries++
+		english, wanted := primary[xWord.Spanish]
+		if !wanted {
+			return nil
+		}
+		mappedWord, exists := processedWords[xWord.Spanish]
+		if !exists {
+			mappedWord = models.Word{
+				Spanish:        xWord.Spanish,
+				EnglishPrimary: english,
+			}
+		}
+		// Every entry is its own sense, with its own type
+		sense := models.ParseWordType(xWord.Type)
+		sense.Gloss = xWord.English
+		mappedWord.Senses = append(mappedWord.Senses, sense)
+		processedWords[xWord.Spanish] = mappedWord
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	fmt.Printf("Read %d dictionary entries, matched %d of %d words\n", entries, len(processedWords), len(primary))
 
 	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			println("Rolling back transaction due to error:", err)
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
 
-	processedWords := make(map[string]models.Word)
-	// Pre-process words for multiple translations
-	for _, xLetter := range xDict.Letters {
-		for _, xWord := range xLetter.Words {
-			mappedWord, exists := processedWords[xWord.Spanish]
-			if exists == true {
-				//println("Found existing word for", xWord.Spanish, "adding translation", xWord.English)
-				mappedWord.English_Translations = append(mappedWord.English_Translations, xWord.English)
-				processedWords[xWord.Spanish] = mappedWord
-			} else {
-				//println("New word for", xWord.Spanish, "with translation", xWord.English)
-				newWord := models.Word{}
-				newWord.English_Translations = []string{xWord.English}
-				newWord.WordType = xWord.Type
-
-				//Add the primary translation if it exists in the tsv file
-				for _, word := range lessonWords {
-					if word.Spanish == xWord.Spanish {
-						newWord.EnglishPrimary = word.English
-					}
-				}
-				newWord.Spanish = xWord.Spanish
-				processedWords[xWord.Spanish] = newWord
-			}
+	// Insert in frequency order so ids are stable between runs
+	for _, lessonWord := range lessonWords {
+		word, exists := processedWords[lessonWord.Spanish]
+		if !exists {
+			continue
 		}
-	}
-	fmt.Printf("Processed %d unique words from XML\n", len(processedWords))
-
-	for _, word := range processedWords {
-		err := insertWord(tx, word)
-		if err != nil {
-			fmt.Println("Error inserting word:", err)
-			return err
+		delete(processedWords, lessonWord.Spanish)
+		if err := models.InsertWord(tx, word); err != nil {
+			return fmt.Errorf("failed to insert %s: %w", word.Spanish, err)
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		println("Rolling back transaction due to error:", err)
-		return err
-	}
-	return nil
-}
-
-// Try insert a word, takes a word model. If the word already exists, it will just append the english translation
-func insertWord(tx *sql.Tx, word models.Word) error {
-	// Insert new word
-	_, err := tx.Exec(
-		"INSERT INTO words (spanish, english_primary, english_translations, word_type) VALUES (?, ?, ?, ?)",
-		word.Spanish, word.EnglishPrimary, strings.Join(word.English_Translations, ","), word.WordType)
-	return err
+	return tx.Commit()
 }
 
-// CreateLessons creates lessons with 30 unique words each from the vocabulary table
+// createLessons creates lessons of lessonSize unique words each from the vocabulary table
 func createLessons(db *sql.DB, words []XmlWord, lessonSize int) error {
 
 	// Pre-load all word IDs once instead of querying for each lesson
@@ -237,12 +300,11 @@ func createLessons(db *sql.DB, words []XmlWord, lessonSize int) error {
 }
 
 // Parse words from file (1000 words.txt) and return array of words
-func parseLessonWords(filePath string) []XmlWord {
+func parseLessonWords(filePath string) ([]XmlWord, error) {
 	// Open the TSV file
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to open word list: %w", err)
 	}
 	defer file.Close()
 
@@ -264,8 +326,7 @@ func parseLessonWords(filePath string) []XmlWord {
 		if lineNumber == 1 {
 			// Verify headers
 			if len(fields) != 3 || fields[0] != "Number" || fields[1] != "Spanish" || fields[2] != "in English" {
-				fmt.Println("Error: Invalid header format. Expected 'Number\tSpanish\tin English'")
-				os.Exit(1)
+				return nil, fmt.Errorf("invalid header format in %s, expected 'Number\tSpanish\tin English'", filePath)
 			}
 			continue // Skip header row
 		}
@@ -292,32 +353,30 @@ func parseLessonWords(filePath string) []XmlWord {
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to read word list: %w", err)
 	}
 
 	// Insert words into the database
 	if len(words) == 0 {
-		fmt.Println("No valid words to insert.")
-		return nil
+		return nil, fmt.Errorf("no valid words in %s", filePath)
 	}
 
 	fmt.Println("Finished processing words.")
-	return words
+	return words, nil
 }
 
-func askForConfirmation(s string) bool {
-	reader := bufio.NewReader(os.Stdin)
+func askForConfirmation(in io.Reader, s string) (bool, error) {
+	reader := bufio.NewReader(in)
 	fmt.Printf("%s [y/N]: ", s)
 	response, err := reader.ReadString('\n')
-	if err != nil {
-		panic(err)
+	if err != nil && err != io.EOF {
+		return false, err
 	}
 	response = strings.ToLower(strings.TrimSpace(response))
 	if response == "y" || response == "yes" {
-		return true
+		return true, nil
 	}
-	return false
+	return false, nil
 }
 
 // chunkWords splits a slice of words into chunks of lessonSize
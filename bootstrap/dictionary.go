@@ -0,0 +1,42 @@
+package bootstrap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+)
+
+// readDictionary streams the <w> entries of an xml dictionary to fn one at a time.
+// Expected layout is <dic><l><w><c>spanish</c><d>english</d><t>{type}</t></w>...</l>...</dic>
+func readDictionary(path string, fn func(XmlWord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse dictionary: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "w" {
+			continue
+		}
+		var xWord XmlWord
+		if err := decoder.DecodeElement(&xWord, &start); err != nil {
+			line, _ := decoder.InputPos()
+			return fmt.Errorf("failed to parse dictionary entry on line %d: %w", line, err)
+		}
+		if err := fn(xWord); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const usage = `usage: lomo config <command> [arguments]
+
+commands:
+  path                 print where the config file is
+  get [name]           print a setting, or every setting
+  set <name> <value>   change a setting
+
+settings:
+  shuffle       shuffle the words of a lesson (true or false)
+  direction     forward, reverse or mixed
+  grading       strict, normal or lenient
+  daily_goal    words to get right each day, 0 for no goal
+  theme         colour scheme, a built in one or one from themes
+  log_file      where debug logging goes (default the XDG state directory)
+  lesson_size   words per lesson when running lomo bootstrap
+  input_width   width of the answer input
+  unlock_mastery
+                percentage of a lesson to master before the next one unlocks
+  unlock_all    open every lesson from the start (true or false)
+  mistakes_days days of answers to look back over when reviewing mistakes
+  leech_lapses  wrong answers or peeks before a word is flagged as a leech
+  cloze         fill words into their example sentences (true or false)
+  conjugation_tenses
+                tenses asked in the conjugation drill, e.g. '["present", "preterite"]'
+  timed_word_seconds
+                seconds to answer each word in a timed run
+  timed_run_seconds
+                length of a timed run in seconds
+  speech        text to speech program: auto, espeak-ng, espeak, say or none
+  voice         text to speech voice (default the program's Spanish voice)
+  keys.<action> key bindings, e.g. lomo config set keys.peek ctrl+p
+  themes.<name>.<colour>
+                colours of your own theme, e.g. lomo config set themes.mine.accent "#ff8800"
+`
+
+// Command runs the `lomo config` subcommands
+func Command(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(usage)
+		return nil
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "path":
+		fmt.Println(path)
+	case "get":
+		if len(args) > 2 {
+			return fmt.Errorf("usage: lomo config get [name]")
+		}
+		cfg, err := Read(path)
+		if err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			value, err := cfg.Get(args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatValue(value))
+			return nil
+		}
+		for _, name := range Names() {
+			value, _ := cfg.Get(name)
+			fmt.Printf("%s = %s\n", name, formatValue(value))
+		}
+		for _, action := range slices.Sorted(maps.Keys(cfg.Keys)) {
+			fmt.Printf("keys.%s = %s\n", action, formatValue(cfg.Keys[action]))
+		}
+		for _, theme := range slices.Sorted(maps.Keys(cfg.Themes)) {
+			for _, role := range slices.Sorted(maps.Keys(cfg.Themes[theme])) {
+				fmt.Printf("themes.%s.%s = %s\n", theme, role, formatValue(cfg.Themes[theme][role]))
+			}
+		}
+	case "set":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: lomo config set <name> <value>")
+		}
+		if err := Set(path, args[1], strings.Join(args[2:], " ")); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s in %s\n", args[1], path)
+	default:
+		fmt.Print(usage)
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+	return nil
+}
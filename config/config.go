@@ -0,0 +1,457 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/conjugate"
+	"github.com/decarlec/lomo/speech"
+)
+
+// File is the name of the config file in the config directory
+const File = "config.toml"
+
+// Direction is which way round words are asked
+type Direction string
+
+const (
+	// Show the word in the pack's language and ask for its translation
+	Forward Direction = "forward"
+	// Show the translation and ask for the word
+	Reverse Direction = "reverse"
+	// Pick a direction at random for each word
+	Mixed Direction = "mixed"
+)
+
+// Grading is how closely an answer has to match to count as correct
+type Grading string
+
+const (
+	// The whole translation, or one of its comma separated parts
+	Strict Grading = "strict"
+	// Any word or phrase of a translation
+	Normal Grading = "normal"
+	// Like normal, also ignoring accents and allowing a typo in longer words
+	Lenient Grading = "lenient"
+)
+
+// Config holds the user's settings
+type Config struct {
+	Shuffle       bool      // shuffle the words of a lesson
+	Direction     Direction // which way round words are asked
+	Grading       Grading   // how closely answers must match
+	DailyGoal     int       // words to get right each day, 0 for no goal
+	Theme         string    // colour scheme
+	LogFile       string    // where debug logging goes, the state directory when empty
+	LessonSize    int       // words per lesson when building a words database
+	InputWidth    int       // width of the answer input
+	UnlockMastery int       // percentage of a lesson's words to master before the next lesson unlocks
+	UnlockAll     bool      // open every lesson from the start
+	MistakesDays  int       // how far back reviewing mistakes looks
+	LeechLapses   int       // wrong answers before a word is a leech
+	Cloze         bool      // ask for words blanked out of their example sentences
+	Tenses        []string  // tenses asked in the conjugation drill
+	WordSeconds   int       // time to answer each word in a timed run
+	TimedSeconds  int       // length of a timed run
+	Speech        string    // text to speech backend, see speech.Backends
+	Voice         string    // text to speech voice, the backend's Spanish voice when empty
+
+	// Key bindings by action, e.g. "peek" = ["ctrl+p"]. Actions left out keep their defaults.
+	Keys map[string][]string
+	// User themes by name, each a colour by role, e.g. "primary" = "#ff00ff"
+	Themes map[string]map[string]string
+}
+
+// Default is the config used when there is no config file
+func Default() Config {
+	return Config{
+		Direction:     Forward,
+		Grading:       Normal,
+		DailyGoal:     20,
+		Theme:         "default",
+		LessonSize:    30,
+		InputWidth:    30,
+		UnlockMastery: 80,
+		MistakesDays:  7,
+		LeechLapses:   8,
+		Tenses:        tenseNames(),
+		WordSeconds:   10,
+		TimedSeconds:  120,
+		Speech:        speech.Auto,
+		Keys:          map[string][]string{},
+		Themes:        map[string]map[string]string{},
+	}
+}
+
+// setting is a value that can be set at the top level of the config file
+type setting struct {
+	name string
+	get  func(c *Config) any
+	set  func(c *Config, value any) error
+}
+
+var settings = []setting{
+	{
+		name: "shuffle",
+		get:  func(c *Config) any { return c.Shuffle },
+		set:  func(c *Config, value any) error { return setBool(&c.Shuffle, value) },
+	},
+	{
+		name: "direction",
+		get:  func(c *Config) any { return string(c.Direction) },
+		set: func(c *Config, value any) error {
+			return setChoice(&c.Direction, value, Forward, Reverse, Mixed)
+		},
+	},
+	{
+		name: "grading",
+		get:  func(c *Config) any { return string(c.Grading) },
+		set: func(c *Config, value any) error {
+			return setChoice(&c.Grading, value, Strict, Normal, Lenient)
+		},
+	},
+	{
+		name: "daily_goal",
+		get:  func(c *Config) any { return c.DailyGoal },
+		set:  func(c *Config, value any) error { return setInt(&c.DailyGoal, value, 0) },
+	},
+	{
+		name: "theme",
+		get:  func(c *Config) any { return c.Theme },
+		set:  func(c *Config, value any) error { return setString(&c.Theme, value, false) },
+	},
+	{
+		name: "log_file",
+		get:  func(c *Config) any { return c.LogFile },
+		set:  func(c *Config, value any) error { return setString(&c.LogFile, value, true) },
+	},
+	{
+		name: "lesson_size",
+		get:  func(c *Config) any { return c.LessonSize },
+		set:  func(c *Config, value any) error { return setInt(&c.LessonSize, value, 1) },
+	},
+	{
+		name: "input_width",
+		get:  func(c *Config) any { return c.InputWidth },
+		set:  func(c *Config, value any) error { return setInt(&c.InputWidth, value, 1) },
+	},
+	{
+		name: "unlock_mastery",
+		get:  func(c *Config) any { return c.UnlockMastery },
+		set: func(c *Config, value any) error {
+			if err := setInt(&c.UnlockMastery, value, 0); err != nil {
+				return err
+			}
+			if c.UnlockMastery > 100 {
+				return fmt.Errorf("must be a percentage, at most 100")
+			}
+			return nil
+		},
+	},
+	{
+		name: "unlock_all",
+		get:  func(c *Config) any { return c.UnlockAll },
+		set:  func(c *Config, value any) error { return setBool(&c.UnlockAll, value) },
+	},
+	{
+		name: "mistakes_days",
+		get:  func(c *Config) any { return c.MistakesDays },
+		set:  func(c *Config, value any) error { return setInt(&c.MistakesDays, value, 1) },
+	},
+	{
+		name: "leech_lapses",
+		get:  func(c *Config) any { return c.LeechLapses },
+		set:  func(c *Config, value any) error { return setInt(&c.LeechLapses, value, 1) },
+	},
+	{
+		name: "cloze",
+		get:  func(c *Config) any { return c.Cloze },
+		set:  func(c *Config, value any) error { return setBool(&c.Cloze, value) },
+	},
+	{
+		name: "conjugation_tenses",
+		get:  func(c *Config) any { return c.Tenses },
+		set: func(c *Config, value any) error {
+			tenses, err := stringList(value)
+			if err != nil {
+				return err
+			}
+			if len(tenses) == 0 {
+				return fmt.Errorf("must have at least one tense")
+			}
+			for _, tense := range tenses {
+				if _, err := conjugate.ParseTense(tense); err != nil {
+					return fmt.Errorf("%w, expected %s", err, strings.Join(tenseNames(), ", "))
+				}
+			}
+			c.Tenses = tenses
+			return nil
+		},
+	},
+	{
+		name: "timed_word_seconds",
+		get:  func(c *Config) any { return c.WordSeconds },
+		set:  func(c *Config, value any) error { return setInt(&c.WordSeconds, value, 1) },
+	},
+	{
+		name: "timed_run_seconds",
+		get:  func(c *Config) any { return c.TimedSeconds },
+		set:  func(c *Config, value any) error { return setInt(&c.TimedSeconds, value, 1) },
+	},
+	{
+		name: "speech",
+		get:  func(c *Config) any { return c.Speech },
+		set: func(c *Config, value any) error {
+			return setChoice(&c.Speech, value, speech.Backends()...)
+		},
+	},
+	{
+		name: "voice",
+		get:  func(c *Config) any { return c.Voice },
+		set:  func(c *Config, value any) error { return setString(&c.Voice, value, true) },
+	},
+}
+
+func tenseNames() []string {
+	names := make([]string, len(conjugate.Tenses))
+	for i, tense := range conjugate.Tenses {
+		names[i] = string(tense)
+	}
+	return names
+}
+
+// Names lists the settings in the order they are documented
+func Names() []string {
+	names := make([]string, len(settings))
+	for i, s := range settings {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Get returns the value of a setting by name, e.g. "grading" or "keys.peek"
+func (c Config) Get(name string) (any, error) {
+	if action, ok := strings.CutPrefix(name, "keys."); ok {
+		keys, ok := c.Keys[action]
+		if !ok {
+			return nil, fmt.Errorf("%s is not set, the default is used", name)
+		}
+		return keys, nil
+	}
+	if theme, role, ok := themeRole(name); ok {
+		colour, ok := c.Themes[theme][role]
+		if !ok {
+			return nil, fmt.Errorf("%s is not set", name)
+		}
+		return colour, nil
+	}
+	s, err := find(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(&c), nil
+}
+
+// themeRole splits a setting like themes.mine.primary into its theme and role
+func themeRole(name string) (theme string, role string, ok bool) {
+	rest, ok := strings.CutPrefix(name, "themes.")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndexByte(rest, '.')
+	if i <= 0 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// Dir returns the directory lomo's config lives in
+func Dir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "lomo"), nil
+}
+
+// Path returns where the config file is, it may not exist
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, File), nil
+}
+
+// StateDir returns the directory lomo keeps logs in, creating it if needed
+func StateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	dir = filepath.Join(dir, "lomo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return dir, nil
+}
+
+// LogPath is where debug logging is written
+func (c Config) LogPath() (string, error) {
+	if c.LogFile != "" {
+		return c.LogFile, nil
+	}
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "debug.log"), nil
+}
+
+// Load reads the config file, falling back to the defaults when there isn't one
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Config{}, err
+	}
+	return Read(path)
+}
+
+// Read reads the config file at path, falling back to the defaults when it doesn't exist
+func Read(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Default(), fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := parse(string(data))
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parse reads the text of a config file over the defaults
+func parse(text string) (Config, error) {
+	cfg := Default()
+	values, err := parseTOML(strings.NewReader(text))
+	if err != nil {
+		return cfg, err
+	}
+	for name, value := range values {
+		if action, ok := strings.CutPrefix(name, "keys."); ok {
+			keys, err := stringList(value)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", name, err)
+			}
+			cfg.Keys[action] = keys
+			continue
+		}
+		if theme, role, ok := themeRole(name); ok {
+			colour, ok := value.(string)
+			if !ok {
+				return cfg, fmt.Errorf("%s: expected a string", name)
+			}
+			if cfg.Themes[theme] == nil {
+				cfg.Themes[theme] = map[string]string{}
+			}
+			cfg.Themes[theme][role] = colour
+			continue
+		}
+		s, err := find(name)
+		if err != nil {
+			return cfg, err
+		}
+		if err := s.set(&cfg, value); err != nil {
+			return cfg, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return cfg, nil
+}
+
+func find(name string) (setting, error) {
+	i := slices.IndexFunc(settings, func(s setting) bool { return s.name == name })
+	if i < 0 {
+		return setting{}, fmt.Errorf("unknown setting %s, expected one of %s keys.<action> or themes.<name>.<colour>", name, strings.Join(Names(), ", "))
+	}
+	return settings[i], nil
+}
+
+func setBool(field *bool, value any) error {
+	b, ok := value.(bool)
+	if !ok {
+		return fmt.Errorf("expected true or false")
+	}
+	*field = b
+	return nil
+}
+
+func setInt(field *int, value any, minimum int) error {
+	n, ok := value.(int64)
+	if !ok {
+		return fmt.Errorf("expected a whole number")
+	}
+	if n < int64(minimum) {
+		return fmt.Errorf("must be at least %d", minimum)
+	}
+	*field = int(n)
+	return nil
+}
+
+func setString(field *string, value any, allowEmpty bool) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a string")
+	}
+	if s == "" && !allowEmpty {
+		return fmt.Errorf("must not be empty")
+	}
+	*field = s
+	return nil
+}
+
+func setChoice[T ~string](field *T, value any, choices ...T) error {
+	s, ok := value.(string)
+	if !ok || !slices.Contains(choices, T(s)) {
+		names := make([]string, len(choices))
+		for i, choice := range choices {
+			names[i] = string(choice)
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(names, ", "))
+	}
+	*field = T(s)
+	return nil
+}
+
+// stringList accepts a single string or an array of strings
+func stringList(value any) ([]string, error) {
+	switch value := value.(type) {
+	case string:
+		return []string{value}, nil
+	case []any:
+		list := make([]string, len(value))
+		for i, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a list of strings")
+			}
+			list[i] = s
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("expected a string or a list of strings")
+}
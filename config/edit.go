@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/assets"
+)
+
+// Set changes one setting in the config file at path, e.g. Set(path, "grading", "lenient").
+// The rest of the file, including comments, is left as it is. Nothing is written if the
+// resulting config is invalid.
+func Set(path string, name string, raw string) error {
+	// Bare text that isn't a TOML value is taken as a string
+	value, err := parseValue(strings.TrimSpace(raw))
+	if err != nil {
+		value = raw
+	}
+
+	table, key := "", name
+	if action, ok := strings.CutPrefix(name, "keys."); ok {
+		table, key = "keys", action
+	} else if theme, role, ok := themeRole(name); ok {
+		if strings.IndexFunc(theme, func(r rune) bool { return !isBareKeyRune(r) }) >= 0 {
+			return fmt.Errorf("theme names may only use letters, digits, - and _")
+		}
+		table, key = "themes."+theme, role
+		// Colours are strings even when they are ANSI numbers
+		value = raw
+	} else if _, err := find(name); err != nil {
+		return err
+	}
+
+	text := ""
+	data, err := os.ReadFile(path)
+	if err == nil {
+		text = string(data)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	text = setLine(text, table, key, formatValue(value))
+	cfg, err := parse(text)
+	if err != nil {
+		return err
+	}
+	if err := assets.CheckTheme(cfg.Theme, cfg.Themes); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// setLine sets table's key to value wherever the file already sets it, whether on its own line in
+// the [table], as a dotted key such as keys.peek or inside an inline table such as keys = { peek = "/" }.
+// Otherwise it's added to the end of the table, next to other dotted keys of the table, or in a new table.
+func setLine(text string, table string, key string, value string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+	target := key
+	if table != "" {
+		target = table + "." + key
+	}
+
+	current := ""
+	tableEnd := -1 // index after the last setting of the [table]
+	if table == "" {
+		tableEnd = 0
+	}
+	dottedEnd, dottedIn := -1, "" // index after the last dotted key inside table, and the table it's in
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(stripComment(lines[i]))
+		if strings.HasPrefix(trimmed, "[") {
+			name, err := parseKey(strings.Trim(trimmed, "[]"))
+			if err == nil {
+				current = name
+			}
+			if current == table {
+				tableEnd = i + 1
+			}
+			continue
+		}
+		rawKey, rawValue, found := strings.Cut(trimmed, "=")
+		name, err := parseKey(rawKey)
+		if !found || err != nil {
+			continue
+		}
+		if current != "" {
+			name = current + "." + name
+		}
+		// The rest of a value spread over several lines
+		end := i + 1
+		for open := openBrackets(trimmed); open > 0 && end < len(lines); end++ {
+			open += openBrackets(stripComment(lines[end]))
+		}
+		// Keep the line's indent, key and trailing comment as they were written
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		comment := ""
+		if end == i+1 {
+			comment = strings.TrimPrefix(lines[i], stripComment(lines[i]))
+			if comment != "" {
+				comment = " " + comment
+			}
+		}
+		written := indent + strings.TrimSpace(rawKey) + " = "
+
+		switch rawValue = strings.TrimSpace(rawValue); {
+		case name == target:
+			return join(slices.Concat(lines[:i], []string{written + value + comment}, lines[end:]))
+		case strings.HasPrefix(target, name+".") && strings.HasPrefix(rawValue, "{"):
+			inline := setInline(rawValue, strings.TrimPrefix(target, name+"."), value)
+			return join(slices.Concat(lines[:i], []string{written + inline + comment}, lines[end:]))
+		case current == table:
+			tableEnd = end
+		case table != "" && strings.HasPrefix(name, table+"."):
+			dottedEnd, dottedIn = end, current
+		}
+	}
+
+	line := key + " = " + value
+	switch {
+	case tableEnd >= 0:
+		inserted := []string{line}
+		if table == "" && tableEnd < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[tableEnd]), "[") {
+			// Keep top level settings apart from the first table
+			inserted = append(inserted, "")
+		}
+		return join(slices.Concat(lines[:tableEnd], inserted, lines[tableEnd:]))
+	case dottedEnd >= 0:
+		// The table is only written as dotted keys, a [table] header for it would define it twice
+		dotted := target
+		if dottedIn != "" {
+			dotted = strings.TrimPrefix(target, dottedIn+".")
+		}
+		return join(slices.Concat(lines[:dottedEnd], []string{dotted + " = " + value}, lines[dottedEnd:]))
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	return join(append(lines, "["+table+"]", line))
+}
+
+// setInline sets the value at the dotted path in an inline table, { peek = "/", next = "tab" }
+func setInline(raw string, path string, value string) string {
+	items := []string{}
+	found := false
+	for _, item := range splitItems(strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}")) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rawKey, rawValue, ok := strings.Cut(item, "=")
+		name, err := parseKey(rawKey)
+		if ok && err == nil && !found {
+			rawValue = strings.TrimSpace(rawValue)
+			switch {
+			case name == path:
+				item, found = strings.TrimSpace(rawKey)+" = "+value, true
+			case strings.HasPrefix(path, name+".") && strings.HasPrefix(rawValue, "{"):
+				item, found = strings.TrimSpace(rawKey)+" = "+setInline(rawValue, strings.TrimPrefix(path, name+"."), value), true
+			}
+		}
+		items = append(items, item)
+	}
+	if !found {
+		items = append(items, path+" = "+value)
+	}
+	return "{ " + strings.Join(items, ", ") + " }"
+}
+
+func join(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatValue writes a value back out as TOML
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []string:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const commented = `# lomo settings
+grading = "lenient" # forgive accents
+lesson_size = 20
+
+# Keys for moving around
+[keys]
+next = [
+  "tab", # the usual
+  "ctrl+n",
+]
+peek = "/"
+
+[themes.mine]
+base = "light"
+`
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+		check func(Config) bool
+	}{
+		{
+			name:  "replaces a top level setting",
+			key:   "lesson_size",
+			value: "40",
+			want: `# lomo settings
+grading = "lenient" # forgive accents
+lesson_size = 40
+
+# Keys for moving around
+[keys]
+next = [
+  "tab", # the usual
+  "ctrl+n",
+]
+peek = "/"
+
+[themes.mine]
+base = "light"
+`,
+			check: func(c Config) bool { return c.LessonSize == 40 },
+		},
+		{
+			name:  "adds a top level setting before the first table",
+			key:   "daily_goal",
+			value: "5",
+			want: `# lomo settings
+grading = "lenient" # forgive accents
+lesson_size = 20
+daily_goal = 5
+
+# Keys for moving around
+[keys]
+next = [
+  "tab", # the usual
+  "ctrl+n",
+]
+peek = "/"
+
+[themes.mine]
+base = "light"
+`,
+			check: func(c Config) bool { return c.DailyGoal == 5 },
+		},
+		{
+			name:  "replaces a key spread over several lines",
+			key:   "keys.next",
+			value: `["n"]`,
+			want: `# lomo settings
+grading = "lenient" # forgive accents
+lesson_size = 20
+
+# Keys for moving around
+[keys]
+next = ["n"]
+peek = "/"
+
+[themes.mine]
+base = "light"
+`,
+			check: func(c Config) bool { return slices.Equal(c.Keys["next"], []string{"n"}) },
+		},
+		{
+			name:  "adds a theme colour to its table",
+			key:   "themes.mine.primary",
+			value: "212",
+			want: `# lomo settings
+grading = "lenient" # forgive accents
+lesson_size = 20
+
+# Keys for moving around
+[keys]
+next = [
+  "tab", # the usual
+  "ctrl+n",
+]
+peek = "/"
+
+[themes.mine]
+base = "light"
+primary = "212"
+`,
+			check: func(c Config) bool { return c.Themes["mine"]["primary"] == "212" },
+		},
+		{
+			name:  "adds a missing table",
+			key:   "themes.other.base",
+			value: "gruvbox",
+			want: commented + `
+[themes.other]
+base = "gruvbox"
+`,
+			check: func(c Config) bool { return c.Themes["other"]["base"] == "gruvbox" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(commented), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := Set(path, tt.key, tt.value); err != nil {
+				t.Fatalf("Set(%q, %q) returned error: %v", tt.key, tt.value, err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Set(%q, %q) wrote\n%s\nwant\n%s", tt.key, tt.value, data, tt.want)
+			}
+			cfg, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() after Set returned error: %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("Read() after Set(%q, %q) = %+v", tt.key, tt.value, cfg)
+			}
+		})
+	}
+}
+
+func TestSetDottedAndInline(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "keeps a trailing comment",
+			text:  "grading = \"lenient\" # forgive accents\n",
+			key:   "grading",
+			value: "strict",
+			want:  "grading = \"strict\" # forgive accents\n",
+		},
+		{
+			name:  "replaces a top level dotted key",
+			text:  "grading = \"strict\"\nkeys.peek = \"?\"\n",
+			key:   "keys.peek",
+			value: "/",
+			want:  "grading = \"strict\"\nkeys.peek = \"/\"\n",
+		},
+		{
+			name:  "adds next to top level dotted keys",
+			text:  "keys.peek = \"?\"\n\n[themes.mine]\nbase = \"light\"\n",
+			key:   "keys.next",
+			value: "n",
+			want:  "keys.peek = \"?\"\nkeys.next = \"n\"\n\n[themes.mine]\nbase = \"light\"\n",
+		},
+		{
+			name:  "adds next to dotted keys in a parent table",
+			text:  "[themes]\nmine.base = \"light\"\n",
+			key:   "themes.mine.primary",
+			value: "212",
+			want:  "[themes]\nmine.base = \"light\"\nmine.primary = \"212\"\n",
+		},
+		{
+			name:  "replaces a key in an inline table",
+			text:  "keys = { peek = \"?\", next = \"tab\" } # mine\n",
+			key:   "keys.peek",
+			value: "/",
+			want:  "keys = { peek = \"/\", next = \"tab\" } # mine\n",
+		},
+		{
+			name:  "adds a key to an inline table",
+			text:  "keys = { peek = \"?\" }\n",
+			key:   "keys.next",
+			value: `["tab", "n"]`,
+			want:  "keys = { peek = \"?\", next = [\"tab\", \"n\"] }\n",
+		},
+		{
+			name:  "replaces a key in a nested inline table",
+			text:  "themes = { mine = { base = \"light\", primary = \"1\" } }\n",
+			key:   "themes.mine.primary",
+			value: "212",
+			want:  "themes = { mine = { base = \"light\", primary = \"212\" } }\n",
+		},
+		{
+			name:  "replaces a dotted key in a table's inline table",
+			text:  "[themes]\nmine = { base = \"light\" }\n",
+			key:   "themes.mine.base",
+			value: "gruvbox",
+			want:  "[themes]\nmine = { base = \"gruvbox\" }\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.text), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := Set(path, tt.key, tt.value); err != nil {
+				t.Fatalf("Set(%q, %q) returned error: %v", tt.key, tt.value, err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Set(%q, %q) wrote\n%s\nwant\n%s", tt.key, tt.value, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetInvalidLeavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(commented), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range [][2]string{{"lesson_size", "many"}, {"grading", "harsh"}, {"nonsense", "1"}} {
+		if err := Set(path, set[0], set[1]); err == nil {
+			t.Errorf("Set(%q, %q) returned no error", set[0], set[1])
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != commented {
+		t.Errorf("invalid Set changed the file to\n%s", data)
+	}
+}
+
+func TestSetCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lomo", "config.toml")
+	if err := Set(path, "keys.peek", "?"); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[keys]\npeek = \"?\"\n"; string(data) != want {
+		t.Errorf("Set() wrote %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML reads a config file, returning its values by their dotted path, e.g. "keys.peek".
+// Tables, whether written as [headers], dotted keys or inline tables, are spread out into the paths of their values.
+func parseTOML(r io.Reader) (map[string]any, error) {
+	tree := map[string]any{}
+	if _, err := toml.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	flatten(values, "", tree)
+	return values, nil
+}
+
+func flatten(values map[string]any, prefix string, table map[string]any) {
+	for key, value := range table {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flatten(values, key, nested)
+			continue
+		}
+		values[key] = value
+	}
+}
+
+// parseValue parses a single TOML value such as "lenient", 30 or ["tab", "n"]
+func parseValue(raw string) (any, error) {
+	var doc struct{ Value any }
+	if _, err := toml.Decode("value = "+raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid value %s", raw)
+	}
+	return doc.Value, nil
+}
+
+// The rest reads just enough of a line for Set to edit the file in place, keeping its comments.
+
+// openBrackets counts the [ and { in line that haven't been closed, ignoring those inside strings
+func openBrackets(line string) int {
+	open := 0
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '{':
+			open++
+		case r == ']' || r == '}':
+			open--
+		}
+	}
+	return open
+}
+
+// stripComment removes a trailing # comment, ignoring # inside strings
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseKey parses a possibly dotted key such as themes."my theme".primary
+func parseKey(raw string) (string, error) {
+	parts := []string{}
+	rest := strings.TrimSpace(raw)
+	for {
+		var part string
+		if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "'") {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				return "", fmt.Errorf("unterminated key %q", raw)
+			}
+			part, rest = rest[1:end+1], strings.TrimSpace(rest[end+2:])
+		} else {
+			end := strings.IndexByte(rest, '.')
+			if end < 0 {
+				end = len(rest)
+			}
+			part, rest = strings.TrimSpace(rest[:end]), rest[end:]
+			if part == "" || strings.IndexFunc(part, func(r rune) bool { return !isBareKeyRune(r) }) >= 0 {
+				return "", fmt.Errorf("invalid key %q", raw)
+			}
+		}
+		parts = append(parts, part)
+		if rest == "" {
+			return strings.Join(parts, "."), nil
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return "", fmt.Errorf("invalid key %q", raw)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+}
+
+func isBareKeyRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// splitItems splits the items of an array or inline table on commas outside strings and nested brackets
+func splitItems(raw string) []string {
+	items := []string{}
+	var quote byte
+	escaped := false
+	depth := 0
+	start := 0
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, raw[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, raw[start:])
+}
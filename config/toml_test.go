@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]any
+	}{
+		{
+			name: "scalars",
+			text: "lesson_size = 30\nshuffle = true\ngrading = \"lenient\"\nbig = 1_000",
+			want: map[string]any{"lesson_size": int64(30), "shuffle": true, "grading": "lenient", "big": int64(1000)},
+		},
+		{
+			name: "tables and dotted keys",
+			text: "themes.other.accent = \"5\"\n[keys]\npeek = \"/\"\n\n[themes.\"my theme\"]\nprimary = \"212\"",
+			want: map[string]any{"themes.other.accent": "5", "keys.peek": "/", "themes.my theme.primary": "212"},
+		},
+		{
+			name: "comments",
+			text: "# a comment\ngrading = \"strict\" # trailing\nvoice = \"es#419\" # the # inside the string stays",
+			want: map[string]any{"grading": "strict", "voice": "es#419"},
+		},
+		{
+			name: "quoting",
+			text: "a = \"tab\\there \\\"quoted\\\"\"\nb = 'C:\\path\\no escapes'\n\"quoted key\" = 'x'\n'single' = \"\"",
+			want: map[string]any{"a": "tab\there \"quoted\"", "b": `C:\path\no escapes`, "quoted key": "x", "single": ""},
+		},
+		{
+			name: "arrays",
+			text: "[keys]\nnext = [\"tab\", \"ctrl+n\"]\nempty = []\nmixed = [1, 'a,b', true,]",
+			want: map[string]any{"keys.next": []any{"tab", "ctrl+n"}, "keys.empty": []any{}, "keys.mixed": []any{int64(1), "a,b", true}},
+		},
+		{
+			name: "array over several lines",
+			text: "[keys]\nnext = [\n  \"tab\", # the usual\n  \"]\",\n]\nprev = \"shift+tab\"",
+			want: map[string]any{"keys.next": []any{"tab", "]"}, "keys.prev": "shift+tab"},
+		},
+		{
+			name: "inline tables",
+			text: "themes.mine = { base = \"light\", primary = \"#7D56F4\" }\nkeys = { next = [\"tab\", \"n\"], nested = { deep = 1 } }\nnone = {}",
+			want: map[string]any{
+				"themes.mine.base": "light", "themes.mine.primary": "#7D56F4",
+				"keys.next": []any{"tab", "n"}, "keys.nested.deep": int64(1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(strings.NewReader(tt.text))
+			if err != nil {
+				t.Fatalf("parseTOML() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"missing value", "a = 1\nb =", 2},
+		{"no equals", "a = 1\n\n# comment\nb", 4},
+		{"unterminated string", "a = \"open", 1},
+		{"invalid key", "a b = 1", 1},
+		{"set twice", "[keys]\npeek = \"/\"\npeek = \"?\"", 3},
+		{"set twice by an inline table", "keys.peek = \"/\"\nkeys = { peek = \"?\" }", 2},
+		{"unterminated inline table", "a = 1\nb = { c = 1", 2},
+		{"error after a multi line array", "a = [\n1,\n2,\n]\nb = nope", 5},
+		{"error inside a multi line array", "a = [\n1,\nnope,\n]", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(strings.NewReader(tt.text))
+			var parseErr toml.ParseError
+			if !errors.As(err, &parseErr) || parseErr.Position.Line != tt.line {
+				t.Errorf("parseTOML() error = %v, want one on line %d", err, tt.line)
+			}
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want any
+	}{
+		{`"lenient"`, "lenient"},
+		{"30", int64(30)},
+		{"true", true},
+		{`["tab", "n"]`, []any{"tab", "n"}},
+	}
+	for _, tt := range tests {
+		got, err := parseValue(tt.raw)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValue(%q) = %#v, %v, want %#v", tt.raw, got, err, tt.want)
+		}
+	}
+	if _, err := parseValue("lenient"); err == nil {
+		t.Errorf("parseValue(%q) returned no error", "lenient")
+	}
+}
@@ -0,0 +1,413 @@
+// Package conjugate conjugates Spanish verbs. Regular verbs follow their -ar, -er or -ir
+// endings with the usual spelling changes, everything else comes from irregular.tsv.
+package conjugate
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// Tense is a tense or mood a verb can be conjugated in
+type Tense string
+
+const (
+	Present     Tense = "present"
+	Preterite   Tense = "preterite"
+	Imperfect   Tense = "imperfect"
+	Future      Tense = "future"
+	Conditional Tense = "conditional"
+	Subjunctive Tense = "subjunctive" // present subjunctive
+)
+
+// Tenses are every tense that can be conjugated, in the order they're usually learned
+var Tenses = []Tense{Present, Preterite, Imperfect, Future, Conditional, Subjunctive}
+
+// ParseTense returns the tense with the given name
+func ParseTense(name string) (Tense, error) {
+	for _, tense := range Tenses {
+		if string(tense) == name {
+			return tense, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tense %q", name)
+}
+
+// Person is who does the action, the row of a conjugation table
+type Person int
+
+const (
+	Yo Person = iota
+	Tu
+	El
+	Nosotros
+	Vosotros
+	Ellos
+)
+
+// Persons are every person in table order
+var Persons = []Person{Yo, Tu, El, Nosotros, Vosotros, Ellos}
+
+func (p Person) String() string {
+	return [...]string{"yo", "tú", "él/ella/usted", "nosotros", "vosotros", "ellos/ellas/ustedes"}[p]
+}
+
+// Forms are a tense's conjugations, indexed by Person
+type Forms [6]string
+
+// irregularData lists what regular rules get wrong, one "infinitive<TAB>kind<TAB>values" per line.
+// A kind is either a tense with its six forms, "stem change" such as e>ie or o>ue,u,
+// "future stem" for the future and conditional, or "like" followed by the verb it's a prefixed form of.
+//
+//go:embed irregular.tsv
+var irregularData string
+
+// verb is what irregular.tsv says about a verb
+type verb struct {
+	forms      map[Tense]Forms
+	stemChange string
+	futureStem string
+	like       string
+}
+
+var irregular = mustParse(irregularData)
+
+func mustParse(data string) map[string]verb {
+	verbs, err := parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("bad irregular verb data: %v", err))
+	}
+	return verbs
+}
+
+func parse(data string) (map[string]verb, error) {
+	verbs := map[string]verb{}
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r", ""), "\n") {
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 columns, got %d", i+1, len(fields))
+		}
+		infinitive, kind, values := fields[0], fields[1], fields[2:]
+		v := verbs[infinitive]
+		if v.forms == nil {
+			v.forms = map[Tense]Forms{}
+		}
+		switch kind {
+		case "stem change":
+			v.stemChange = values[0]
+		case "future stem":
+			v.futureStem = values[0]
+		case "like":
+			if !strings.HasSuffix(infinitive, values[0]) {
+				return nil, fmt.Errorf("line %d: %s doesn't end in %s", i+1, infinitive, values[0])
+			}
+			v.like = values[0]
+		default:
+			tense, err := ParseTense(kind)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if len(values) != len(Persons) {
+				return nil, fmt.Errorf("line %d: expected %d forms, got %d", i+1, len(Persons), len(values))
+			}
+			v.forms[tense] = Forms(values)
+		}
+		verbs[infinitive] = v
+	}
+	return verbs, nil
+}
+
+// Reflexive pronouns put before a reflexive verb's forms
+var reflexivePronouns = Forms{"me", "te", "se", "nos", "os", "se"}
+
+// IsVerb reports whether infinitive looks like a Spanish infinitive that can be conjugated
+func IsVerb(infinitive string) bool {
+	_, err := Table(infinitive, Present)
+	return err == nil
+}
+
+// Conjugate returns infinitive's form for the person in the tense
+func Conjugate(infinitive string, tense Tense, person Person) (string, error) {
+	forms, err := Table(infinitive, tense)
+	if err != nil {
+		return "", err
+	}
+	return forms[person], nil
+}
+
+// Table returns every form of infinitive in the tense. Reflexive verbs such as
+// sentarse get their pronoun, "me siento".
+func Table(infinitive string, tense Tense) (Forms, error) {
+	infinitive = normalize(infinitive)
+	base, reflexive := infinitive, false
+	if cut, ok := strings.CutSuffix(infinitive, "se"); ok && known(cut) {
+		base, reflexive = cut, true
+	}
+	forms, err := conjugate(base, tense)
+	if err != nil {
+		return Forms{}, err
+	}
+	if reflexive {
+		for i := range forms {
+			forms[i] = reflexivePronouns[i] + " " + forms[i]
+		}
+	}
+	return forms, nil
+}
+
+func normalize(infinitive string) string {
+	return strings.ToLower(strings.TrimSpace(infinitive))
+}
+
+// known reports whether infinitive has an ending that can be conjugated
+func known(infinitive string) bool {
+	_, _, ok := split(infinitive)
+	return ok
+}
+
+// split returns an infinitive's stem and class, "ar", "er" or "ir". -ír verbs such as reír are -ir verbs.
+func split(infinitive string) (string, string, bool) {
+	for _, suffix := range []string{"ar", "er", "ir", "ír"} {
+		class := strings.ReplaceAll(suffix, "í", "i")
+		if stem, ok := strings.CutSuffix(infinitive, suffix); ok && !strings.ContainsAny(stem, " -") {
+			// Only ir itself has no stem
+			if _, irregular := irregular[infinitive]; stem == "" && !irregular {
+				continue
+			}
+			return stem, class, true
+		}
+	}
+	return "", "", false
+}
+
+var endings = map[string]map[Tense]Forms{
+	"ar": {
+		Present:     {"o", "as", "a", "amos", "áis", "an"},
+		Preterite:   {"é", "aste", "ó", "amos", "asteis", "aron"},
+		Imperfect:   {"aba", "abas", "aba", "ábamos", "abais", "aban"},
+		Subjunctive: {"e", "es", "e", "emos", "éis", "en"},
+	},
+	"er": {
+		Present:     {"o", "es", "e", "emos", "éis", "en"},
+		Preterite:   {"í", "iste", "ió", "imos", "isteis", "ieron"},
+		Imperfect:   {"ía", "ías", "ía", "íamos", "íais", "ían"},
+		Subjunctive: {"a", "as", "a", "amos", "áis", "an"},
+	},
+	"ir": {
+		Present:     {"o", "es", "e", "imos", "ís", "en"},
+		Preterite:   {"í", "iste", "ió", "imos", "isteis", "ieron"},
+		Imperfect:   {"ía", "ías", "ía", "íamos", "íais", "ían"},
+		Subjunctive: {"a", "as", "a", "amos", "áis", "an"},
+	},
+}
+
+// Future and conditional endings go on the whole infinitive
+var (
+	futureEndings      = Forms{"é", "ás", "á", "emos", "éis", "án"}
+	conditionalEndings = Forms{"ía", "ías", "ía", "íamos", "íais", "ían"}
+)
+
+// jPreteriteEndings follow the j of -ducir preterites, which drop the i of -ieron: tradujeron
+var jPreteriteEndings = Forms{"e", "iste", "o", "imos", "isteis", "eron"}
+
+func conjugate(infinitive string, tense Tense) (Forms, error) {
+	v := irregular[infinitive]
+	if forms, ok := v.forms[tense]; ok {
+		return forms, nil
+	}
+	if v.like != "" {
+		prefix := strings.TrimSuffix(infinitive, v.like)
+		forms, err := conjugate(v.like, tense)
+		for i := range forms {
+			forms[i] = prefix + forms[i]
+		}
+		return forms, err
+	}
+
+	stem, class, ok := split(infinitive)
+	if !ok {
+		return Forms{}, fmt.Errorf("%q isn't a Spanish infinitive", infinitive)
+	}
+	var forms Forms
+	switch tense {
+	case Present:
+		for _, p := range Persons {
+			s := stem
+			if p != Nosotros && p != Vosotros {
+				s = changeStem(s, v.stemChange, false)
+			}
+			ending := endings[class][tense][p]
+			forms[p] = spell(s, infinitive, ending) + ending
+		}
+	case Preterite:
+		// -ducir verbs swap the c for j, traduje, conduje
+		if strings.HasSuffix(infinitive, "ducir") {
+			for _, p := range Persons {
+				forms[p] = strings.TrimSuffix(stem, "c") + "j" + jPreteriteEndings[p]
+			}
+			break
+		}
+		for _, p := range Persons {
+			s := stem
+			if class == "ir" && (p == El || p == Ellos) {
+				s = changeStem(s, v.stemChange, true)
+			}
+			forms[p] = spell(s, infinitive, endings[class][tense][p]) + preteriteEnding(s, class, p)
+		}
+	case Imperfect:
+		for _, p := range Persons {
+			forms[p] = stem + endings[class][tense][p]
+		}
+	case Future, Conditional:
+		future := infinitive
+		if v.futureStem != "" {
+			future = v.futureStem
+		}
+		for _, p := range Persons {
+			if tense == Future {
+				forms[p] = future + futureEndings[p]
+			} else {
+				forms[p] = future + conditionalEndings[p]
+			}
+		}
+	case Subjunctive:
+		return subjunctive(infinitive, stem, class, v)
+	default:
+		return Forms{}, fmt.Errorf("unknown tense %q", tense)
+	}
+	return forms, nil
+}
+
+// preteriteEnding accents the endings of stems ending in a vowel, creí, creíste, creyó,
+// and turns i into y between vowels
+func preteriteEnding(stem, class string, p Person) string {
+	ending := endings[class][Preterite][p]
+	if class == "ar" || !endsInVowel(stem) || strings.HasSuffix(stem, "gu") || strings.HasSuffix(stem, "qu") {
+		return ending
+	}
+	switch p {
+	case El:
+		return "yó"
+	case Ellos:
+		return "yeron"
+	}
+	// -uir verbs keep the plain i, construiste
+	if strings.HasSuffix(stem, "u") || p == Yo {
+		return ending
+	}
+	return "í" + strings.TrimPrefix(ending, "i")
+}
+
+// subjunctive builds on the present yo form, tenga from tengo, except nosotros and
+// vosotros of stem changing verbs which keep the infinitive's stem, pensemos
+func subjunctive(infinitive, stem, class string, v verb) (Forms, error) {
+	present, err := conjugate(infinitive, Present)
+	if err != nil {
+		return Forms{}, err
+	}
+	yoStem, ok := strings.CutSuffix(present[Yo], "o")
+	if !ok {
+		return Forms{}, fmt.Errorf("no subjunctive for %q", infinitive)
+	}
+	var forms Forms
+	for _, p := range Persons {
+		ending := endings[class][Subjunctive][p]
+		s := yoStem
+		if v.stemChange != "" && (p == Nosotros || p == Vosotros) {
+			s = stem
+			if class == "ir" {
+				s = changeStem(s, v.stemChange, true)
+			}
+		}
+		forms[p] = spell(s, infinitive, ending) + ending
+	}
+	return forms, nil
+}
+
+// changeStem applies a stem change such as "e>ie" or "o>ue,u" to the last matching vowel of the stem.
+// The change after the comma is used by -ir verbs where the stressed change isn't,
+// durmió, durmamos. e>i verbs use i for both.
+func changeStem(stem, change string, unstressed bool) string {
+	from, to, ok := strings.Cut(change, ">")
+	if !ok {
+		return stem
+	}
+	stressed, other, hasOther := strings.Cut(to, ",")
+	if unstressed {
+		switch {
+		case hasOther:
+			to = other
+		case stressed == "i":
+			to = "i"
+		default:
+			return stem
+		}
+	} else {
+		to = stressed
+	}
+	i := strings.LastIndex(stem, from)
+	if i < 0 {
+		return stem
+	}
+	return stem[:i] + to + stem[i+len(from):]
+}
+
+// spell changes the end of the stem so it keeps its sound before the ending:
+// busqué, llegué, averigüé, empecé, recojo, consigo, crezco, construyo
+func spell(stem, infinitive, ending string) string {
+	if ending == "" {
+		return stem
+	}
+	next := ending[0]
+	front := next == 'e' || strings.HasPrefix(ending, "é")
+	back := next == 'o' || next == 'a' || strings.HasPrefix(ending, "á")
+	switch {
+	case strings.HasSuffix(infinitive, "ar"):
+		if !front {
+			return stem
+		}
+		switch {
+		case strings.HasSuffix(stem, "gu"):
+			return strings.TrimSuffix(stem, "gu") + "gü"
+		case strings.HasSuffix(stem, "c"):
+			return strings.TrimSuffix(stem, "c") + "qu"
+		case strings.HasSuffix(stem, "g"):
+			return stem + "u"
+		case strings.HasSuffix(stem, "z"):
+			return strings.TrimSuffix(stem, "z") + "c"
+		}
+	case strings.HasSuffix(infinitive, "guir"):
+		if back && strings.HasSuffix(stem, "gu") {
+			return strings.TrimSuffix(stem, "u")
+		}
+	case strings.HasSuffix(infinitive, "uir") && !strings.HasSuffix(infinitive, "quir"):
+		if (back || front) && !strings.HasSuffix(stem, "y") {
+			return stem + "y"
+		}
+	case strings.HasSuffix(infinitive, "ger") || strings.HasSuffix(infinitive, "gir"):
+		if back && strings.HasSuffix(stem, "g") {
+			return strings.TrimSuffix(stem, "g") + "j"
+		}
+	case strings.HasSuffix(infinitive, "cer") || strings.HasSuffix(infinitive, "cir"):
+		if !back || !strings.HasSuffix(stem, "c") || strings.HasSuffix(stem, "zc") {
+			return stem
+		}
+		// conozco after a vowel, venzo after a consonant
+		if len(stem) > 1 && isVowel(stem[len(stem)-2]) {
+			return strings.TrimSuffix(stem, "c") + "zc"
+		}
+		return strings.TrimSuffix(stem, "c") + "z"
+	}
+	return stem
+}
+
+func endsInVowel(stem string) bool {
+	return stem != "" && isVowel(stem[len(stem)-1])
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
@@ -0,0 +1,127 @@
+package conjugate
+
+import "testing"
+
+func TestConjugate(t *testing.T) {
+	tests := []struct {
+		infinitive string
+		tense      Tense
+		person     Person
+		want       string
+	}{
+		// Regular
+		{"hablar", Present, Nosotros, "hablamos"},
+		{"comer", Preterite, Ellos, "comieron"},
+		{"vivir", Imperfect, Tu, "vivías"},
+		{"hablar", Future, Vosotros, "hablaréis"},
+		{"comer", Conditional, Yo, "comería"},
+		{"vivir", Subjunctive, El, "viva"},
+
+		// Stem changing
+		{"pensar", Present, Yo, "pienso"},
+		{"pensar", Subjunctive, Nosotros, "pensemos"},
+		{"volver", Present, Ellos, "vuelven"},
+		{"jugar", Present, El, "juega"},
+		{"pedir", Present, Tu, "pides"},
+		{"pedir", Preterite, El, "pidió"},
+		{"dormir", Preterite, Ellos, "durmieron"},
+		{"dormir", Subjunctive, Nosotros, "durmamos"},
+		{"sentir", Subjunctive, Vosotros, "sintáis"},
+		{"enviar", Present, Yo, "envío"},
+		{"almorzar", Present, Yo, "almuerzo"},
+		{"almorzar", Present, El, "almuerza"},
+		{"almorzar", Subjunctive, Yo, "almuerce"},
+		{"oler", Present, Yo, "huelo"},
+		{"oler", Present, Tu, "hueles"},
+		{"oler", Present, Nosotros, "olemos"},
+		{"oler", Subjunctive, El, "huela"},
+		{"oler", Subjunctive, Nosotros, "olamos"},
+		{"errar", Present, Yo, "yerro"},
+		{"errar", Present, Vosotros, "erráis"},
+		{"errar", Subjunctive, Ellos, "yerren"},
+		{"adquirir", Present, Yo, "adquiero"},
+		{"adquirir", Present, Nosotros, "adquirimos"},
+		{"adquirir", Preterite, El, "adquirió"},
+		{"adquirir", Subjunctive, Tu, "adquieras"},
+		{"adquirir", Subjunctive, Nosotros, "adquiramos"},
+		{"prohibir", Present, Yo, "prohíbo"},
+		{"prohibir", Present, Nosotros, "prohibimos"},
+		{"prohibir", Subjunctive, Ellos, "prohíban"},
+
+		// Spelling changes
+		{"buscar", Preterite, Yo, "busqué"},
+		{"llegar", Subjunctive, El, "llegue"},
+		{"empezar", Preterite, Yo, "empecé"},
+		{"empezar", Subjunctive, Yo, "empiece"},
+		{"averiguar", Subjunctive, Yo, "averigüe"},
+		{"averiguar", Preterite, Yo, "averigüé"},
+		{"averiguar", Present, Yo, "averiguo"},
+		{"coger", Present, Yo, "cojo"},
+		{"seguir", Present, Yo, "sigo"},
+		{"conocer", Present, Yo, "conozco"},
+		{"vencer", Present, Yo, "venzo"},
+		{"construir", Present, Ellos, "construyen"},
+		{"construir", Preterite, El, "construyó"},
+		{"leer", Preterite, Tu, "leíste"},
+		{"creer", Preterite, Ellos, "creyeron"},
+		{"traducir", Present, Yo, "traduzco"},
+		{"traducir", Preterite, Yo, "traduje"},
+		{"traducir", Preterite, El, "tradujo"},
+		{"traducir", Preterite, Ellos, "tradujeron"},
+		{"conducir", Subjunctive, Nosotros, "conduzcamos"},
+
+		// Irregular
+		{"ser", Present, Tu, "eres"},
+		{"ir", Imperfect, Nosotros, "íbamos"},
+		{"tener", Present, Yo, "tengo"},
+		{"tener", Subjunctive, Ellos, "tengan"},
+		{"tener", Future, Yo, "tendré"},
+		{"mantener", Preterite, El, "mantuvo"},
+		{"decir", Conditional, Tu, "dirías"},
+		{"hacer", Preterite, El, "hizo"},
+		{"valer", Present, Yo, "valgo"},
+		{"valer", Future, Yo, "valdré"},
+		{"valer", Subjunctive, Nosotros, "valgamos"},
+		{"oír", Present, Ellos, "oyen"},
+		{"reír", Present, Yo, "río"},
+		{"reír", Preterite, Ellos, "rieron"},
+		{"reír", Imperfect, Yo, "reía"},
+		{"reír", Future, Nosotros, "reiremos"},
+		{"reír", Subjunctive, Nosotros, "riamos"},
+		{"sonreír", Present, El, "sonríe"},
+
+		// Reflexive
+		{"sentarse", Present, Yo, "me siento"},
+		{"reírse", Preterite, El, "se rió"},
+	}
+	for _, tt := range tests {
+		got, err := Conjugate(tt.infinitive, tt.tense, tt.person)
+		if err != nil {
+			t.Errorf("Conjugate(%q, %s, %s) returned error: %v", tt.infinitive, tt.tense, tt.person, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Conjugate(%q, %s, %s) = %q, want %q", tt.infinitive, tt.tense, tt.person, got, tt.want)
+		}
+	}
+}
+
+func TestIsVerb(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"hablar", true},
+		{"ir", true},
+		{"reír", true},
+		{"Sentarse", true},
+		{"casa", false},
+		{"ar", false},
+		{"tomar el sol", false},
+	}
+	for _, tt := range tests {
+		if got := IsVerb(tt.word); got != tt.want {
+			t.Errorf("IsVerb(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
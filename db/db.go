@@ -1,43 +1,74 @@
 package db
 
-import ( 
-	"embed"
+import (
+	"database/sql"
+	"database/sql/driver"
+	_ "embed"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
-	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 //go:embed words.db
-var embeddedDB embed.FS
+var embeddedDB []byte
 
 // The public db instance
 var DB *sql.DB
 
-// initDB initializes the database connection
+// Path of the pack database attached to every connection as "pack"
+var packPath string
 
-func InitDB() error {
-	dbContent, err := embeddedDB.ReadFile("words.db")
-	if err != nil {
-		log.Fatalf("Error reading embedded database: %v", err)
-	}
+const driverName = "sqlite3_lomo"
 
-	// Create a temporary file to store the database content
-	tempFile, err := os.CreateTemp("", "embedded-db-*.db")
-	if err != nil {
-		log.Fatalf("Error creating temporary file: %v", err)
+func init() {
+	// Every pooled connection needs the pack attached, so do it in the connect hook
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if packPath == "" {
+				return nil
+			}
+			_, err := conn.Exec("ATTACH DATABASE ? AS pack", []driver.Value{packPath})
+			return err
+		},
+	})
+}
+
+// Embedded returns the contents of the built in words database
+func Embedded() []byte {
+	return embeddedDB
+}
+
+// DataDir returns the directory lomo keeps packs and progress in, creating it if needed
+func DataDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	dir = filepath.Join(dir, "lomo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
 	}
-	defer tempFile.Close()
+	return dir, nil
+}
 
-	_, err = tempFile.Write(dbContent)
-	if err != nil {
-		log.Fatalf("Error writing to temporary file: %v", err)
+// InitDB opens the user's progress database with the given pack database attached.
+// Pack tables (words, lessons) are read from the pack, everything else lives in progress.
+func InitDB(pack string, progress string) error {
+	if err := os.MkdirAll(filepath.Dir(progress), 0o755); err != nil {
+		return fmt.Errorf("failed to create progress directory: %w", err)
 	}
+	packPath = pack
 
 	// Start up the database
-	DB, err = sql.Open("sqlite3", tempFile.Name())
+	var err error
+	DB, err = sql.Open(driverName, progress)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -46,7 +77,10 @@ func InitDB() error {
 	if err := DB.Ping(); err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+
+	if err := migrate(DB); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
 	log.Println("Database connection initialized")
 	return nil
 }
-
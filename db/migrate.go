@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Migrations for the progress database, applied in order and tracked with PRAGMA user_version.
+// Only ever append to this list.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS main.users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    emails TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE TABLE IF NOT EXISTS main.history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lesson_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    correct_ids TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO main.users (name) VALUES ('default');`,
+
+	`CREATE TABLE IF NOT EXISTS main.sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    lesson_id INTEGER NOT NULL,
+    current INTEGER NOT NULL DEFAULT 0,
+    input TEXT NOT NULL DEFAULT '',
+    words TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, lesson_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);`,
+
+	`CREATE TABLE IF NOT EXISTS main.answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    lesson_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    correct BOOLEAN NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS main.answers_user_created ON answers (user_id, created_at);`,
+
+	`ALTER TABLE main.answers ADD COLUMN peeked BOOLEAN NOT NULL DEFAULT 0;`,
+
+	`CREATE TABLE IF NOT EXISTS main.word_states (
+    user_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    suspended BOOLEAN NOT NULL DEFAULT 0,
+    reset_at DATETIME,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS main.notes (
+    user_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);`,
+
+	`CREATE TABLE IF NOT EXISTS main.sentences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    spanish TEXT NOT NULL,
+    english TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS main.sentences_word_id ON sentences (word_id);`,
+
+	`ALTER TABLE main.word_states ADD COLUMN gender_streak INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE main.word_states ADD COLUMN gender_answered_at DATETIME;`,
+
+	`ALTER TABLE main.answers ADD COLUMN latency_ms INTEGER;
+
+CREATE TABLE IF NOT EXISTS main.timed_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    lesson_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    accuracy INTEGER NOT NULL,
+    average_latency_ms INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS main.timed_runs_user_lesson ON timed_runs (user_id, lesson_id);`,
+
+	`ALTER TABLE main.answers ADD COLUMN practice BOOLEAN NOT NULL DEFAULT 0;
+UPDATE main.answers SET practice = 1 WHERE latency_ms IS NOT NULL;`,
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA main.user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA main.user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/conjugate"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConjugationModel drills the pack's verbs, asking for one person in one tense at a time
+type ConjugationModel struct {
+	verbs     []models.Word
+	question  conjugationQuestion
+	textInput textinput.Model
+	asked     int // questions moved on from
+	right     int // answered right without peeking or a wrong try
+	help      help.Model
+	size      size
+}
+
+type conjugationQuestion struct {
+	verb    models.Word
+	tense   conjugate.Tense
+	person  conjugate.Person
+	forms   conjugate.Forms
+	correct bool
+	peek    bool
+	missed  bool
+}
+
+// NewConjugationModel drills every verb of the pack that can be conjugated
+func NewConjugationModel() (*ConjugationModel, error) {
+	words, err := models.GetAllWords()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching words: %w", err)
+	}
+	verbs := []models.Word{}
+	for _, word := range words {
+		isVerb := slices.ContainsFunc(word.Senses, func(sense models.Sense) bool { return sense.PartOfSpeech == models.Verb })
+		if isVerb && conjugate.IsVerb(word.Spanish) {
+			verbs = append(verbs, word)
+		}
+	}
+	if len(verbs) == 0 {
+		return nil, fmt.Errorf("no verbs to conjugate in this pack")
+	}
+	log.Printf("Drilling %d verbs\n", len(verbs))
+
+	m := ConjugationModel{verbs: verbs, textInput: getLessonInput(), help: newHelp()}
+	m.question = m.ask()
+	return &m, nil
+}
+
+// ask picks a verb, tense and person at random from the configured tenses
+func (m ConjugationModel) ask() conjugationQuestion {
+	tenses := []conjugate.Tense{}
+	for _, name := range Settings.Tenses {
+		if tense, err := conjugate.ParseTense(name); err == nil {
+			tenses = append(tenses, tense)
+		}
+	}
+	if len(tenses) == 0 {
+		tenses = conjugate.Tenses
+	}
+
+	for {
+		q := conjugationQuestion{
+			verb:   m.verbs[rand.Intn(len(m.verbs))],
+			tense:  tenses[rand.Intn(len(tenses))],
+			person: conjugate.Persons[rand.Intn(len(conjugate.Persons))],
+		}
+		forms, err := conjugate.Table(q.verb.Spanish, q.tense)
+		if err != nil {
+			// Only verbs that conjugate are drilled, but don't get stuck on one that doesn't
+			log.Printf("Error conjugating %s: %v\n", q.verb.Spanish, err)
+			continue
+		}
+		q.forms = forms
+		return q
+	}
+}
+
+// next moves on to a new question
+func (m ConjugationModel) next() ConjugationModel {
+	m.asked++
+	m.question = m.ask()
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = ""
+	return m
+}
+
+func (m ConjugationModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Enter focuses the answer input when the drill is shown
+func (m ConjugationModel) Enter() (tea.Model, tea.Cmd) {
+	return m, m.textInput.Focus()
+}
+
+func (m ConjugationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		_, horizontal := m.size.padding()
+		m.help.Width = m.size.cardWidth() - horizontal
+		m.textInput.Width = max(min(Settings.InputWidth, m.size.cardWidth()-horizontal-4), 1)
+		return m, nil
+	case tea.KeyMsg:
+		q := &m.question
+		switch {
+		case key.Matches(msg, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				log.Printf("Leaving conjugation drill after %d questions\n", m.asked)
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m, nil
+		case key.Matches(msg, Keys.Peek):
+			q.peek = !q.peek
+			return m, nil
+		case key.Matches(msg, Keys.Next):
+			return m.next(), nil
+		case key.Matches(msg, Keys.Submit):
+			if q.correct {
+				return m.next(), nil
+			}
+			q.correct = gradeConjugation(m.textInput.Value(), q.forms[q.person])
+			if q.correct {
+				if !q.peek && !q.missed {
+					m.right++
+				}
+				m.textInput.Placeholder = ""
+				return m, nil
+			}
+			q.missed = strings.TrimSpace(m.textInput.Value()) != "" || q.missed
+			m.textInput.Placeholder = "✗ try again!"
+			m.textInput.PlaceholderStyle.Foreground(assets.Active.Wrong)
+			m.textInput.SetValue("")
+			return m, nil
+		}
+	}
+
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// gradeConjugation checks a typed form, ignoring case and extra spaces
+func gradeConjugation(input string, form string) bool {
+	input = strings.ToLower(strings.Join(strings.Fields(input), " "))
+	return input != "" && matches(input, form, Settings.Grading)
+}
+
+func (m ConjugationModel) View() string {
+	q := m.question
+	done := m.asked
+	if q.correct || q.peek || q.missed {
+		done++
+	}
+	s := fmt.Sprintf("Conjugation - %d/%d right\n\n", m.right, done)
+
+	wordStyle := lipgloss.NewStyle().Bold(true).UnsetPadding().Foreground(assets.Active.Secondary)
+	s += "Verb: " + wordStyle.Render(q.verb.Spanish) +
+		lipgloss.NewStyle().UnsetBold().Render(" - "+q.verb.EnglishPrimary) + "\n"
+	s += "Person: " + wordStyle.Render(q.person.String()) + "\n"
+	s += "Tense: " + wordStyle.Render(string(q.tense)) + "\n"
+	s += m.textInput.View() + "\n"
+
+	if q.correct {
+		s += correctStyle("✓ Correct! \n"+conjugationTable(q), m.size)
+	} else if q.peek {
+		s += peekStyle("? Answer shown \n"+conjugationTable(q), m.size)
+	}
+
+	s += lipgloss.NewStyle().PaddingTop(1).UnsetBold().Render("\n" + m.help.View(conjugationKeys{Keys}))
+	return lessonStyle(s, m.size)
+}
+
+// conjugationTable shows the answer and the rest of the tense, the asked person marked
+func conjugationTable(q conjugationQuestion) string {
+	rows := make([]string, len(q.forms))
+	for _, person := range conjugate.Persons {
+		marker := "  "
+		if person == q.person {
+			marker = "=>"
+		}
+		rows[person] = fmt.Sprintf("%s %-20s %s", marker, person, q.forms[person])
+	}
+	return fmt.Sprintf("Answer: %s\n\n%s of %s:\n%s", q.forms[q.person], q.tense, q.verb.Spanish, strings.Join(rows, "\n"))
+}
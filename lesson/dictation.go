@@ -0,0 +1,76 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/speech"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Voice reads words aloud, set from the speech setting
+var Voice speech.Speaker = speech.Silent{}
+
+// noVoice is shown when there's no text to speech program to speak with
+const noVoice = "Nothing to speak with, install espeak-ng (or say on macOS) or set speech in the config"
+
+// NewDictationLessonModel asks for a lesson's words by speaking them, they're typed in Spanish
+// without being shown. Dictations aren't saved and their answers are practice, they only count towards the daily goal.
+func NewDictationLessonModel(lessonId int64) (*LessonModel, error) {
+	lesson, err := models.GetLessonByID(lessonId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lesson %d: %w", lessonId, err)
+	}
+	words := lesson.Words
+	rand.Shuffle(len(words), func(i, j int) { words[i], words[j] = words[j], words[i] })
+
+	return &LessonModel{
+		Lesson:     *lesson,
+		words:      words,
+		textInput:  getLessonInput(),
+		lessonType: "dictation",
+		reversed:   make([]bool, len(words)),
+		help:       newHelp(),
+	}, nil
+}
+
+func (m LessonModel) IsDictation() bool {
+	return m.lessonType == "dictation"
+}
+
+// speak reads text aloud in the background, showing a toast if it can't
+func speak(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := Voice.Speak(text); err != nil {
+			log.Printf("Error speaking %q: %v\n", text, err)
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't speak %s: %v", text, err)}
+		}
+		return nil
+	}
+}
+
+// speakWord reads the current word aloud, unless hearing it would give the answer away
+func (m LessonModel) speakWord() tea.Cmd {
+	if !speech.Enabled(Voice) {
+		return func() tea.Msg { return messages.ToastMsg{Text: noVoice} }
+	}
+	word := m.words[m.current]
+	if !m.IsDictation() && m.asksSpanish(m.current) && !word.Correct && !word.Peek {
+		return func() tea.Msg {
+			return messages.ToastMsg{Text: "Hearing it would give the answer away, answer or peek first"}
+		}
+	}
+	return speak(word.Spanish)
+}
+
+// announce speaks the word just moved to in a dictation, other lessons show it instead
+func (m LessonModel) announce() tea.Cmd {
+	if !m.IsDictation() || len(m.words) == 0 {
+		return nil
+	}
+	return m.speakWord()
+}
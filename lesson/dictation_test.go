@@ -0,0 +1,135 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/speech"
+)
+
+// recorder is a Speaker that remembers what it was asked to say
+type recorder struct {
+	said *[]string
+}
+
+func (r recorder) Speak(text string) error {
+	*r.said = append(*r.said, text)
+	return nil
+}
+
+// useRecorder swaps Voice for a recorder until the test ends
+func useRecorder(t *testing.T) *[]string {
+	said := []string{}
+	previous := Voice
+	Voice = recorder{&said}
+	t.Cleanup(func() { Voice = previous })
+	return &said
+}
+
+func newTestLesson(lessonType string, reversed bool) LessonModel {
+	return LessonModel{
+		words:      []models.Word{{Id: 1, Spanish: "casa", EnglishPrimary: "house"}},
+		lessonType: lessonType,
+		reversed:   []bool{reversed},
+	}
+}
+
+func TestSpeakWord(t *testing.T) {
+	tests := []struct {
+		name       string
+		lessonType string
+		reversed   bool
+		peek       bool
+		correct    bool
+		wantSaid   []string
+		wantToast  bool
+	}{
+		{name: "forward lesson", lessonType: "normal", wantSaid: []string{"casa"}},
+		{name: "reversed lesson gives the answer away", lessonType: "normal", reversed: true, wantToast: true},
+		{name: "reversed lesson after peeking", lessonType: "normal", reversed: true, peek: true, wantSaid: []string{"casa"}},
+		{name: "reversed lesson after answering", lessonType: "normal", reversed: true, correct: true, wantSaid: []string{"casa"}},
+		{name: "dictation", lessonType: "dictation", wantSaid: []string{"casa"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			said := useRecorder(t)
+			m := newTestLesson(tt.lessonType, tt.reversed)
+			m.words[0].Peek = tt.peek
+			m.words[0].Correct = tt.correct
+
+			msg := m.speakWord()()
+			if _, toast := msg.(messages.ToastMsg); toast != tt.wantToast {
+				t.Errorf("speakWord() sent %#v, want a toast: %v", msg, tt.wantToast)
+			}
+			if !slices.Equal(*said, tt.wantSaid) {
+				t.Errorf("speakWord() said %q, want %q", *said, tt.wantSaid)
+			}
+		})
+	}
+}
+
+func TestSpeakWordWithoutVoice(t *testing.T) {
+	previous := Voice
+	Voice = speech.Silent{}
+	t.Cleanup(func() { Voice = previous })
+
+	msg := newTestLesson("dictation", false).speakWord()()
+	if toast, ok := msg.(messages.ToastMsg); !ok || toast.Text != noVoice {
+		t.Errorf("speakWord() sent %#v, want the no voice toast", msg)
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	said := useRecorder(t)
+	if cmd := newTestLesson("normal", false).announce(); cmd != nil {
+		t.Errorf("announce() in a lesson = %v, want nil", cmd)
+	}
+	if cmd := (LessonModel{lessonType: "dictation"}).announce(); cmd != nil {
+		t.Errorf("announce() in an empty dictation = %v, want nil", cmd)
+	}
+
+	cmd := newTestLesson("dictation", false).announce()
+	if cmd == nil {
+		t.Fatal("announce() in a dictation = nil, want a command speaking the word")
+	}
+	cmd()
+	if !slices.Equal(*said, []string{"casa"}) {
+		t.Errorf("announce() said %q, want [casa]", *said)
+	}
+}
+
+func TestSpeakWordStartingWithDash(t *testing.T) {
+	// A stand-in espeak-ng that writes down its arguments
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + args + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "espeak-ng"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	voice, err := speech.New("espeak-ng", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := Voice
+	Voice = voice
+	t.Cleanup(func() { Voice = previous })
+
+	m := newTestLesson("dictation", false)
+	m.words[0].Spanish = "-ito"
+	if msg := m.speakWord()(); msg != nil {
+		t.Errorf("speakWord() sent %#v, want nothing", msg)
+	}
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(data)), []string{"-v", "es", "--", "-ito"}; !slices.Equal(got, want) {
+		t.Errorf("espeak-ng was run with %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package lesson
+
+import (
+	"log"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrorModel shows something that went wrong, letting the user retry or go back
+type ErrorModel struct {
+	err   error
+	retry tea.Cmd
+	help  help.Model
+	size  size
+}
+
+func NewErrorModel(err error, retry tea.Cmd) ErrorModel {
+	log.Printf("Showing error: %v\n", err)
+	return ErrorModel{err: err, retry: retry, help: newHelp()}
+}
+
+func (m ErrorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ErrorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		m.help.Width = m.size.cardWidth()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keys.Quit, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Retry):
+			if m.retry == nil {
+				return m, nil
+			}
+			// Get rid of this screen before trying again, so a new failure shows a fresh one
+			return m, tea.Sequence(func() tea.Msg {
+				return messages.BackMsg{}
+			}, m.retry)
+		}
+	}
+	return m, nil
+}
+
+func (m ErrorModel) View() string {
+	s := lipgloss.NewStyle().Foreground(assets.Active.Accent).Render("Something went wrong") + "\n\n"
+	s += lipgloss.NewStyle().UnsetBold().Render(m.err.Error()) + "\n\n"
+
+	s += lipgloss.NewStyle().UnsetBold().Render(m.help.View(errorKeys{Keys, m.retry != nil}))
+
+	_, horizontal := m.size.padding()
+	var style = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(assets.Active.Primary).
+		PaddingTop(1).
+		PaddingBottom(1).
+		PaddingLeft(horizontal).
+		PaddingRight(horizontal).
+		Width(m.size.cardWidth()).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(assets.Active.Accent)
+	return style.Render(s)
+}
+
+// Renders a toast shown under the current screen, wrapped to width when it is known
+func ToastView(text string, width int) string {
+	style := lipgloss.NewStyle().Foreground(assets.Active.Accent).Bold(true).PaddingLeft(1)
+	if width > 0 {
+		style = style.Width(width)
+	}
+	return style.Render("! " + text)
+}
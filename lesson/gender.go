@@ -0,0 +1,274 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// GenderModel drills the gender of the pack's nouns by asking for their article
+type GenderModel struct {
+	nouns     []models.Word
+	progress  map[int64]models.GenderProgress
+	queue     []int // nouns still to ask this round, by index
+	question  genderQuestion
+	textInput textinput.Model
+	asked     int // questions moved on from
+	right     int // answered right without peeking or a wrong try
+	help      help.Model
+	size      size
+}
+
+type genderQuestion struct {
+	noun       models.Word
+	definite   string
+	indefinite string
+	correct    bool
+	peek       bool
+	missed     bool
+}
+
+// NewGenderModel drills the masculine and feminine nouns of the pack, leaving out suspended words
+func NewGenderModel() (*GenderModel, error) {
+	words, err := models.GetAllWords()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching words: %w", err)
+	}
+	suspended, err := models.GetSuspendedWordIds(1)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := models.GetGenderProgress(1)
+	if err != nil {
+		return nil, err
+	}
+
+	nouns := []models.Word{}
+	for _, word := range words {
+		// Nouns that are both don't have a gender to learn
+		gender, ok := word.Gender()
+		if ok && gender != models.CommonGender && !suspended[word.Id] {
+			nouns = append(nouns, word)
+		}
+	}
+	if len(nouns) == 0 {
+		return nil, fmt.Errorf("no nouns with a gender in this pack")
+	}
+	log.Printf("Drilling the gender of %d nouns\n", len(nouns))
+
+	m := GenderModel{nouns: nouns, progress: progress, textInput: getLessonInput(), help: newHelp()}
+	m.textInput.Placeholder = ""
+	m = m.ask()
+	return &m, nil
+}
+
+// ask takes the next noun off the queue, refilling it with the nouns not learned yet
+// followed by the learned ones, each shuffled
+func (m GenderModel) ask() GenderModel {
+	if len(m.queue) == 0 {
+		learning, learned := []int{}, []int{}
+		for i, noun := range m.nouns {
+			if m.progress[noun.Id].Mastered() {
+				learned = append(learned, i)
+			} else {
+				learning = append(learning, i)
+			}
+		}
+		rand.Shuffle(len(learning), func(i, j int) { learning[i], learning[j] = learning[j], learning[i] })
+		rand.Shuffle(len(learned), func(i, j int) { learned[i], learned[j] = learned[j], learned[i] })
+		m.queue = append(learning, learned...)
+	}
+
+	noun := m.nouns[m.queue[0]]
+	m.queue = m.queue[1:]
+	definite, indefinite, _ := noun.Articles()
+	m.question = genderQuestion{noun: noun, definite: definite, indefinite: indefinite}
+	return m
+}
+
+// next moves on to the next noun
+func (m GenderModel) next() GenderModel {
+	m.asked++
+	m = m.ask()
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = ""
+	return m
+}
+
+// learned counts the nouns whose gender is mastered
+func (m GenderModel) learned() int {
+	count := 0
+	for _, noun := range m.nouns {
+		if m.progress[noun.Id].Mastered() {
+			count++
+		}
+	}
+	return count
+}
+
+// record stores an answer and keeps the streak shown in step with it
+func (m *GenderModel) record(correct bool) tea.Cmd {
+	wordId := m.question.noun.Id
+	p := m.progress[wordId]
+	p.WordId = wordId
+	p.Streak++
+	if !correct {
+		p.Streak = 0
+	}
+	m.progress[wordId] = p
+	return func() tea.Msg {
+		if err := models.RecordGender(1, wordId, correct); err != nil {
+			log.Printf("Error recording gender answer for word %d: %v\n", wordId, err)
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't save your answer: %v", err)}
+		}
+		return nil
+	}
+}
+
+func (m GenderModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Enter focuses the answer input when the drill is shown
+func (m GenderModel) Enter() (tea.Model, tea.Cmd) {
+	return m, m.textInput.Focus()
+}
+
+func (m GenderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		_, horizontal := m.size.padding()
+		m.help.Width = m.size.cardWidth() - horizontal
+		m.textInput.Width = max(min(Settings.InputWidth, m.size.cardWidth()-horizontal-4), 1)
+		return m, nil
+	case tea.KeyMsg:
+		q := &m.question
+		switch {
+		case key.Matches(msg, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				log.Printf("Leaving gender drill after %d questions\n", m.asked)
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m, nil
+		case key.Matches(msg, Keys.Peek):
+			q.peek = !q.peek
+			// Seeing the answer counts as getting it wrong, once
+			if q.peek && !q.correct && !q.missed {
+				q.missed = true
+				return m, m.record(false)
+			}
+			return m, nil
+		case key.Matches(msg, Keys.Next):
+			return m.next(), nil
+		case key.Matches(msg, Keys.Submit):
+			if q.correct {
+				return m.next(), nil
+			}
+			input := m.textInput.Value()
+			if strings.TrimSpace(input) == "" {
+				return m, nil
+			}
+			q.correct = gradeGender(input, *q)
+			if q.correct {
+				m.textInput.Placeholder = ""
+				if q.peek || q.missed {
+					return m, nil
+				}
+				m.right++
+				return m, m.record(true)
+			}
+			m.textInput.Placeholder = "✗ try again!"
+			m.textInput.PlaceholderStyle.Foreground(assets.Active.Wrong)
+			m.textInput.SetValue("")
+			if q.missed {
+				return m, nil
+			}
+			q.missed = true
+			return m, m.record(false)
+		}
+	}
+
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// gradeGender accepts the noun's definite or indefinite article, alone or followed by the noun
+func gradeGender(input string, q genderQuestion) bool {
+	fields := strings.Fields(strings.ToLower(input))
+	if len(fields) == 0 {
+		return false
+	}
+	article, rest := fields[0], strings.Join(fields[1:], " ")
+	if rest != "" && rest != strings.ToLower(q.noun.Spanish) {
+		return false
+	}
+	if article == q.definite || article == q.indefinite {
+		return true
+	}
+	// Una is also fine before a stressed a, "una agua"
+	gender, _ := q.noun.Gender()
+	return gender == models.Feminine && q.indefinite == "un" && article == "una"
+}
+
+func (m GenderModel) View() string {
+	q := m.question
+	done := m.asked
+	if q.correct || q.peek || q.missed {
+		done++
+	}
+	s := fmt.Sprintf("Gender - %d/%d right, %d/%d nouns learned\n\n", m.right, done, m.learned(), len(m.nouns))
+
+	wordStyle := lipgloss.NewStyle().Bold(true).UnsetPadding().Foreground(assets.Active.Secondary)
+	s += "Noun: " + wordStyle.Render(q.noun.Spanish) +
+		lipgloss.NewStyle().UnsetBold().Render(" - "+q.noun.EnglishPrimary) + "\n"
+	s += fmt.Sprintf("Which article, %s?\n", articleChoices(q))
+	s += m.textInput.View() + "\n"
+
+	if q.correct {
+		s += correctStyle("✓ Correct! \n"+genderAnswer(q, m.progress[q.noun.Id]), m.size)
+	} else if q.peek {
+		s += peekStyle("? Answer shown \n"+genderAnswer(q, m.progress[q.noun.Id]), m.size)
+	}
+
+	s += lipgloss.NewStyle().PaddingTop(1).UnsetBold().Render("\n" + m.help.View(genderKeys{Keys}))
+	return lessonStyle(s, m.size)
+}
+
+// articleChoices offers the articles to pick from, plural ones for plural nouns
+func articleChoices(q genderQuestion) string {
+	if strings.HasSuffix(q.definite, "s") {
+		return "los or las"
+	}
+	return "el or la"
+}
+
+func genderAnswer(q genderQuestion, progress models.GenderProgress) string {
+	s := fmt.Sprintf("%s %s, %s %s", q.definite, q.noun.Spanish, q.indefinite, q.noun.Spanish)
+	if gender, _ := q.noun.Gender(); gender == models.Feminine && q.definite == "el" {
+		s += "\nFeminine, but el comes before a stressed a"
+	}
+	if progress.Mastered() {
+		s += "\n\nLearned"
+	} else {
+		s += fmt.Sprintf("\n\n%d/%d right in a row to learn it", progress.Streak, models.MasteryStreak)
+	}
+	return s
+}
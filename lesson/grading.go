@@ -0,0 +1,98 @@
+package lesson
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/config"
+	"github.com/decarlec/lomo/models"
+)
+
+// grade checks an answer to word. Reversed words are answered with the word itself instead of a translation.
+func grade(input string, word models.Word, reversed bool, grading config.Grading) bool {
+	input = strings.ToLower(strings.Join(strings.Fields(input), " "))
+	if input == "" {
+		return false
+	}
+	inputs := []string{input}
+	if reversed && grading != config.Strict {
+		// Nouns may be given with their article
+		for _, article := range []string{"el ", "la ", "los ", "las ", "un ", "una "} {
+			if rest, ok := strings.CutPrefix(input, article); ok {
+				inputs = append(inputs, rest)
+			}
+		}
+	}
+
+	candidates := answers(word, reversed, grading)
+	matched := slices.ContainsFunc(inputs, func(input string) bool {
+		return slices.ContainsFunc(candidates, func(candidate string) bool {
+			return matches(input, candidate, grading)
+		})
+	})
+	if matched {
+		log.Printf("Matched '%s' for word %d\n", input, word.Id)
+	}
+	return matched
+}
+
+func matches(input string, candidate string, grading config.Grading) bool {
+	if input == candidate {
+		return true
+	}
+	if grading != config.Lenient {
+		return false
+	}
+	a, b := foldAccents(input), foldAccents(candidate)
+	return a == b || len([]rune(b)) >= 5 && editDistance(a, b) <= 1
+}
+
+// answers are the lower case answers accepted for word
+func answers(word models.Word, reversed bool, grading config.Grading) []string {
+	if reversed {
+		return []string{strings.ToLower(word.Spanish)}
+	}
+	accepted := []string{strings.ToLower(strings.TrimSpace(word.EnglishPrimary))}
+	for _, sense := range word.Senses {
+		for _, phrase := range models.GlossPhrases(sense.Gloss) {
+			accepted = append(accepted, phrase)
+			if grading == config.Strict {
+				continue
+			}
+			// Single words of a phrase count too, e.g. "run" for "to run"
+			for _, field := range strings.Fields(phrase) {
+				accepted = append(accepted, strings.Trim(field, "."))
+			}
+		}
+	}
+	return accepted
+}
+
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+func foldAccents(s string) string {
+	return accents.Replace(s)
+}
+
+// editDistance is the number of single letter insertions, deletions or substitutions to turn a into b
+func editDistance(a string, b string) int {
+	x, y := []rune(a), []rune(b)
+	previous := make([]int, len(y)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		current := make([]int, len(y)+1)
+		current[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(y)]
+}
@@ -0,0 +1,317 @@
+package lesson
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/speech"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// KeyMap holds every key binding used by the screens
+type KeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Select    key.Binding
+	Back      key.Binding
+	Quit      key.Binding
+	ForceQuit key.Binding // quits even where letters are typed as answers
+	Help      key.Binding
+	Peek      key.Binding
+	Next      key.Binding
+	Prev      key.Binding
+	Submit    key.Binding
+	Retry     key.Binding
+	Sort      key.Binding
+	Filter    key.Binding
+	Mistakes  key.Binding
+	Suspend   key.Binding
+	Note      key.Binding
+	Reset     key.Binding
+	Save      key.Binding
+	Timed     key.Binding
+	Dictation key.Binding
+	Speak     key.Binding
+}
+
+// Keys are the bindings in use
+var Keys = DefaultKeyMap()
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:        key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:      key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Select:    key.NewBinding(key.WithKeys("enter", " "), key.WithHelp("enter", "select")),
+		Back:      key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+		Quit:      key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
+		ForceQuit: key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+		Help:      key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "toggle help")),
+		Peek:      key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "show answer")),
+		Next:      key.NewBinding(key.WithKeys("right"), key.WithHelp("→", "next word")),
+		Prev:      key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "previous word")),
+		Submit:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "check answer")),
+		Retry:     key.NewBinding(key.WithKeys("r", "enter"), key.WithHelp("r", "try again")),
+		Sort:      key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+		Filter:    key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "filter")),
+		Mistakes:  key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "review mistakes")),
+		Suspend:   key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "suspend")),
+		Note:      key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "edit note")),
+		Reset:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reset")),
+		Save:      key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save")),
+		Timed:     key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "timed run")),
+		Dictation: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "dictation")),
+		Speak:     key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "speak")),
+	}
+}
+
+// actions maps the names used in the config file to their bindings
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":         &k.Up,
+		"down":       &k.Down,
+		"select":     &k.Select,
+		"back":       &k.Back,
+		"quit":       &k.Quit,
+		"force_quit": &k.ForceQuit,
+		"help":       &k.Help,
+		"peek":       &k.Peek,
+		"next":       &k.Next,
+		"prev":       &k.Prev,
+		"submit":     &k.Submit,
+		"retry":      &k.Retry,
+		"sort":       &k.Sort,
+		"filter":     &k.Filter,
+		"mistakes":   &k.Mistakes,
+		"suspend":    &k.Suspend,
+		"note":       &k.Note,
+		"reset":      &k.Reset,
+		"save":       &k.Save,
+		"timed":      &k.Timed,
+		"dictation":  &k.Dictation,
+		"speak":      &k.Speak,
+	}
+}
+
+// The actions each screen responds to, no two of them may share a key.
+// Screens where text is typed can't have actions on letters, digits or space.
+var screenActions = []struct {
+	screen  string
+	actions []string
+	typing  bool
+}{
+	{"main menu", []string{"up", "down", "select", "quit", "force_quit", "help"}, false},
+	{"lesson menu", []string{"up", "down", "select", "sort", "filter", "timed", "dictation", "back", "quit", "force_quit", "help"}, false},
+	{"lesson", []string{"submit", "peek", "prev", "next", "mistakes", "note", "speak", "back", "help", "force_quit"}, true},
+	{"error", []string{"retry", "back", "quit", "force_quit", "help"}, false},
+	{"leeches", []string{"up", "down", "suspend", "note", "reset", "back", "quit", "force_quit", "help"}, false},
+	{"note", []string{"save", "back", "force_quit"}, true},
+	{"conjugation", []string{"submit", "peek", "next", "back", "help", "force_quit"}, true},
+	{"gender", []string{"submit", "peek", "next", "back", "help", "force_quit"}, true},
+	{"timed lesson", []string{"submit", "next", "back", "help", "force_quit"}, true},
+	{"leaderboard", []string{"up", "down", "select", "back", "quit", "force_quit", "help"}, false},
+}
+
+// Key names shown in the help instead of their bubbletea names
+var keySymbols = map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→", " ": "space"}
+
+// ApplyKeyConfig replaces the default bindings of the actions in bindings, e.g. "peek": {"ctrl+p"}.
+// Unknown actions and keys shared by two actions on the same screen are errors.
+func ApplyKeyConfig(bindings map[string][]string) error {
+	keys := DefaultKeyMap()
+	actions := keys.actions()
+	for action, keyNames := range bindings {
+		binding, ok := actions[action]
+		if !ok {
+			names := slices.Sorted(maps.Keys(actions))
+			return fmt.Errorf("unknown key action %q, expected one of %s", action, strings.Join(names, ", "))
+		}
+		symbols := make([]string, len(keyNames))
+		for i, name := range keyNames {
+			symbols[i] = name
+			if symbol, ok := keySymbols[name]; ok {
+				symbols[i] = symbol
+			}
+		}
+		binding.SetKeys(keyNames...)
+		binding.SetHelp(strings.Join(symbols, "/"), binding.Help().Desc)
+	}
+
+	for _, screen := range screenActions {
+		used := map[string]string{}
+		for _, action := range screen.actions {
+			for _, name := range actions[action].Keys() {
+				if other, ok := used[name]; ok {
+					return fmt.Errorf("key %q is bound to both %s and %s on the %s screen", name, other, action, screen.screen)
+				}
+				used[name] = action
+				// Letters typed into an answer would trigger the action instead
+				if r, size := utf8.DecodeRuneInString(name); screen.typing && size == len(name) && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r)) {
+					return fmt.Errorf("key %q can't be bound to %s, it is typed as text on the %s screen", name, action, screen.screen)
+				}
+			}
+		}
+	}
+
+	Keys = keys
+	return nil
+}
+
+// Help for each screen, only showing the keys that screen uses
+
+type mainMenuKeys struct{ KeyMap }
+
+func (k mainMenuKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Quit, k.Help}
+}
+
+func (k mainMenuKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Select}, {k.Help, k.Quit, k.ForceQuit}}
+}
+
+type lessonMenuKeys struct{ KeyMap }
+
+func (k lessonMenuKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Timed, k.Dictation, k.Sort, k.Filter, k.Back, k.Help}
+}
+
+func (k lessonMenuKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Select}, {k.Timed, k.Dictation}, {k.Sort, k.Filter}, {k.Back, k.Help, k.Quit, k.ForceQuit}}
+}
+
+// lessonKeys only shows reviewing mistakes once a normal lesson is finished, and speaking when there is a voice to speak with
+type lessonKeys struct {
+	KeyMap
+	mistakes bool
+}
+
+func (k lessonKeys) ShortHelp() []key.Binding {
+	k.Mistakes.SetEnabled(k.mistakes)
+	k.Speak.SetEnabled(speech.Enabled(Voice))
+	return []key.Binding{k.Mistakes, k.Peek, k.Speak, k.Note, k.Prev, k.Next, k.Back, k.Help}
+}
+
+func (k lessonKeys) FullHelp() [][]key.Binding {
+	k.Mistakes.SetEnabled(k.mistakes)
+	k.Speak.SetEnabled(speech.Enabled(Voice))
+	return [][]key.Binding{{k.Submit, k.Peek, k.Speak}, {k.Prev, k.Next}, {k.Mistakes, k.Note}, {k.Back, k.Help, k.ForceQuit}}
+}
+
+// timedKeys skip a word instead of moving between words, and end the run once it's over
+type timedKeys struct {
+	KeyMap
+	over bool
+}
+
+func (k timedKeys) ShortHelp() []key.Binding {
+	k.Next.SetHelp(k.Next.Help().Key, "skip")
+	if k.over {
+		return []key.Binding{k.Back, k.Help}
+	}
+	return []key.Binding{k.Submit, k.Next, k.Back, k.Help}
+}
+
+func (k timedKeys) FullHelp() [][]key.Binding {
+	k.Next.SetHelp(k.Next.Help().Key, "skip")
+	if k.over {
+		return [][]key.Binding{{k.Back}, {k.Help, k.ForceQuit}}
+	}
+	return [][]key.Binding{{k.Submit, k.Next}, {k.Back, k.Help, k.ForceQuit}}
+}
+
+type leaderboardKeys struct{ KeyMap }
+
+func (k leaderboardKeys) ShortHelp() []key.Binding {
+	k.Select.SetHelp(k.Select.Help().Key, "timed run")
+	return []key.Binding{k.Up, k.Down, k.Select, k.Back, k.Help}
+}
+
+func (k leaderboardKeys) FullHelp() [][]key.Binding {
+	k.Select.SetHelp(k.Select.Help().Key, "timed run")
+	return [][]key.Binding{{k.Up, k.Down, k.Select}, {k.Back, k.Help, k.Quit, k.ForceQuit}}
+}
+
+type leechKeys struct{ KeyMap }
+
+func (k leechKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Suspend, k.Note, k.Reset, k.Back, k.Help}
+}
+
+func (k leechKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down}, {k.Suspend, k.Note, k.Reset}, {k.Back, k.Help, k.Quit, k.ForceQuit}}
+}
+
+type conjugationKeys struct{ KeyMap }
+
+func (k conjugationKeys) ShortHelp() []key.Binding {
+	k.Next.SetHelp(k.Next.Help().Key, "next verb")
+	return []key.Binding{k.Submit, k.Peek, k.Next, k.Back, k.Help}
+}
+
+func (k conjugationKeys) FullHelp() [][]key.Binding {
+	k.Next.SetHelp(k.Next.Help().Key, "next verb")
+	return [][]key.Binding{{k.Submit, k.Peek, k.Next}, {k.Back, k.Help, k.ForceQuit}}
+}
+
+type genderKeys struct{ KeyMap }
+
+func (k genderKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Submit, k.Peek, k.Next, k.Back, k.Help}
+}
+
+func (k genderKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Submit, k.Peek, k.Next}, {k.Back, k.Help, k.ForceQuit}}
+}
+
+// noteKeys has no full help, ? is typed into the note
+type noteKeys struct{ KeyMap }
+
+func (k noteKeys) ShortHelp() []key.Binding {
+	k.Back.SetHelp(k.Back.Help().Key, "cancel")
+	return []key.Binding{k.Save, k.Back}
+}
+
+func (k noteKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type errorKeys struct {
+	KeyMap
+	canRetry bool
+}
+
+func (k errorKeys) ShortHelp() []key.Binding {
+	if k.canRetry {
+		return []key.Binding{k.Retry, k.Back, k.Quit, k.Help}
+	}
+	return []key.Binding{k.Back, k.Quit, k.Help}
+}
+
+func (k errorKeys) FullHelp() [][]key.Binding {
+	if k.canRetry {
+		return [][]key.Binding{{k.Retry, k.Back}, {k.Help, k.Quit, k.ForceQuit}}
+	}
+	return [][]key.Binding{{k.Back}, {k.Help, k.Quit, k.ForceQuit}}
+}
+
+// newHelp creates the help view used at the bottom of every screen
+func newHelp() help.Model {
+	h := help.New()
+	keyStyle := lipgloss.NewStyle().Foreground(assets.Active.Muted).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(assets.Active.Muted)
+	h.Styles.ShortKey = keyStyle
+	h.Styles.FullKey = keyStyle
+	h.Styles.ShortDesc = descStyle
+	h.Styles.FullDesc = descStyle
+	h.Styles.ShortSeparator = descStyle
+	h.Styles.FullSeparator = descStyle
+	h.Styles.Ellipsis = descStyle
+	return h
+}
@@ -0,0 +1,54 @@
+package lesson
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// Terminals narrower than this get the compact layout, without the logo and with less padding
+	compactWidth = 70
+	// Cards don't grow past this, lines get too long to read
+	maxCardWidth = 120
+	// Card width before the terminal size is known
+	defaultCardWidth = 100
+)
+
+// size is the terminal size a screen is laid out for, zero until the first tea.WindowSizeMsg
+type size struct {
+	width  int
+	height int
+}
+
+func newSize(msg tea.WindowSizeMsg) size {
+	return size{width: msg.Width, height: msg.Height}
+}
+
+func (s size) compact() bool {
+	return s.width > 0 && s.width < compactWidth
+}
+
+// cardWidth is the width of a bordered card, not counting the border
+func (s size) cardWidth() int {
+	if s.width == 0 {
+		return defaultCardWidth
+	}
+	return max(min(s.width-2, maxCardWidth), 10)
+}
+
+// padding is the space inside a card, above and below then left
+func (s size) padding() (vertical int, horizontal int) {
+	if s.compact() {
+		return 0, 1
+	}
+	return 2, 4
+}
+
+// visibleRange is the part of a list of count rows to show so the cursor stays on screen
+// when only available rows fit. Without a known height every row is shown.
+func visibleRange(cursor int, count int, available int) (start int, end int) {
+	if available <= 0 || count <= available {
+		return 0, count
+	}
+	start = min(max(cursor-available/2, 0), count-available)
+	return start, start + available
+}
@@ -0,0 +1,150 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// LeaderboardModel lists the user's best timed run of each lesson
+type LeaderboardModel struct {
+	bests  []models.TimedRun
+	cursor int
+	help   help.Model
+	size   size
+}
+
+func NewLeaderboardModel() (*LeaderboardModel, error) {
+	bests, err := models.GetPersonalBests(1)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Found personal bests for %d lessons\n", len(bests))
+	return &LeaderboardModel{bests: bests, help: newHelp()}, nil
+}
+
+func (m LeaderboardModel) Init() tea.Cmd {
+	return nil
+}
+
+// Enter reloads the personal bests, a timed run may have just finished
+func (m LeaderboardModel) Enter() (tea.Model, tea.Cmd) {
+	leaderboard, err := NewLeaderboardModel()
+	if err != nil {
+		// Keep showing what we had
+		return m, func() tea.Msg {
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't refresh the leaderboard: %v", err)}
+		}
+	}
+	leaderboard.cursor = min(m.cursor, max(len(leaderboard.bests)-1, 0))
+	leaderboard.help = m.help
+	leaderboard.size = m.size
+	return *leaderboard, nil
+}
+
+func (m LeaderboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		m.help.Width = msg.Width
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keys.Quit, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, Keys.Down):
+			if m.cursor < len(m.bests)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, Keys.Select):
+			if len(m.bests) == 0 {
+				return m, nil
+			}
+			lessonId := m.bests[m.cursor].LessonId
+			return m, func() tea.Msg {
+				log.Printf("Starting a timed run of lesson %d from the leaderboard\n", lessonId)
+				return messages.SwitchToLessonMsg{LessonId: lessonId, Timed: true}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m LeaderboardModel) View() string {
+	title := headerStyle().Render("Leaderboard - your best timed run of each lesson")
+	helpView := m.help.View(leaderboardKeys{Keys})
+	if len(m.bests) == 0 {
+		empty := lipgloss.NewStyle().Foreground(assets.Active.Accent).Render(
+			fmt.Sprintf("No timed runs yet, press %s on a lesson in the lesson menu to race the clock", Keys.Timed.Help().Key))
+		return title + "\n" + empty + "\n\n" + helpView + "\n"
+	}
+
+	compact := m.size.compact()
+	rows := make([][]string, len(m.bests))
+	for i, run := range m.bests {
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "=>"
+		}
+		rows[i] = []string{
+			fmt.Sprintf("%s Lesson %d", cursor, run.LessonId),
+			fmt.Sprintf("%d", run.Score),
+			fmt.Sprintf("%d/%d", run.Correct, run.Total),
+		}
+		if !compact {
+			rows[i] = append(rows[i],
+				fmt.Sprintf("%d%%", run.Accuracy),
+				fmt.Sprintf("%.1fs", run.AverageLatency.Seconds()),
+				fmt.Sprintf("%d", run.Runs),
+				run.CreatedAt.Local().Format("Jan 2"))
+		}
+	}
+	headers := []string{"Lesson", "Score", "Correct"}
+	if !compact {
+		headers = append(headers, "Accuracy", "Avg answer", "Runs", "Date")
+	}
+
+	// Borders, header and the line under it, then the title and help
+	available := 0
+	if m.size.height > 0 {
+		available = max(m.size.height-4-lipgloss.Height(title)-lipgloss.Height(helpView)-1, 1)
+	}
+	start, end := visibleRange(m.cursor, len(rows), available)
+	rows = rows[start:end]
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(assets.Active.Primary).Bold(true)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if row == table.HeaderRow {
+				return style.Foreground(assets.Active.Primary).Bold(true)
+			}
+			return style.Foreground(assets.Active.Accent)
+		}).
+		Headers(headers...).
+		Rows(rows...)
+	if m.size.width > 0 {
+		t = t.Width(min(lipgloss.Width(t.Render()), m.size.width))
+	}
+
+	return title + "\n" + t.Render() + "\n" + helpView + "\n"
+}
@@ -0,0 +1,190 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// LeechesModel lists the words answered wrong over and over, to suspend, annotate or reset them
+type LeechesModel struct {
+	leeches []models.Leech
+	cursor  int
+	help    help.Model
+	size    size
+}
+
+func NewLeechesModel() (*LeechesModel, error) {
+	leeches, err := models.GetLeeches(1, Settings.LeechLapses)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Found %d leeches\n", len(leeches))
+	return &LeechesModel{leeches: leeches, help: newHelp()}, nil
+}
+
+func (m LeechesModel) Init() tea.Cmd {
+	return nil
+}
+
+// Enter reloads the leeches, a note may have been edited
+func (m LeechesModel) Enter() (tea.Model, tea.Cmd) {
+	return m.reload()
+}
+
+func (m LeechesModel) reload() (tea.Model, tea.Cmd) {
+	leeches, err := NewLeechesModel()
+	if err != nil {
+		// Keep showing what we had
+		return m, func() tea.Msg {
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't refresh leeches: %v", err)}
+		}
+	}
+	leeches.cursor = min(m.cursor, max(len(leeches.leeches)-1, 0))
+	leeches.help = m.help
+	leeches.size = m.size
+	return *leeches, nil
+}
+
+// change runs a change to the selected word then reloads, or shows a toast if it fails
+func (m LeechesModel) change(what string, apply func(word models.Word) error) (tea.Model, tea.Cmd) {
+	word := m.leeches[m.cursor].Word
+	if err := apply(word); err != nil {
+		log.Printf("Error trying to %s word %d: %v\n", what, word.Id, err)
+		return m, func() tea.Msg {
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't %s %s: %v", what, word.Spanish, err)}
+		}
+	}
+	return m.reload()
+}
+
+func (m LeechesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		m.help.Width = msg.Width
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keys.Quit, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, Keys.Down):
+			if m.cursor < len(m.leeches)-1 {
+				m.cursor++
+			}
+		}
+		if len(m.leeches) == 0 {
+			return m, nil
+		}
+		leech := m.leeches[m.cursor]
+		switch {
+		case key.Matches(msg, Keys.Suspend):
+			return m.change("suspend", func(word models.Word) error {
+				return models.SetSuspended(1, word.Id, !leech.Suspended)
+			})
+		case key.Matches(msg, Keys.Reset):
+			return m.change("reset", func(word models.Word) error {
+				return models.ResetWord(1, word.Id)
+			})
+		case key.Matches(msg, Keys.Note):
+			return m, func() tea.Msg {
+				return messages.SwitchToNoteMsg{WordId: leech.Word.Id}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m LeechesModel) View() string {
+	title := headerStyle().Render(fmt.Sprintf("Leeches - words with %d or more lapses", Settings.LeechLapses))
+	helpView := m.help.View(leechKeys{Keys})
+	if len(m.leeches) == 0 {
+		empty := lipgloss.NewStyle().Foreground(assets.Active.Accent).Render(
+			"No leeches, words you get wrong or peek at over and over will show up here")
+		return title + "\n" + empty + "\n\n" + helpView + "\n"
+	}
+
+	compact := m.size.compact()
+	rows := make([][]string, len(m.leeches))
+	for i, leech := range m.leeches {
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "=>"
+		}
+		state := ""
+		if leech.Suspended {
+			state = "suspended"
+		}
+		rows[i] = []string{cursor + " " + leech.Word.DisplaySpanish(), fmt.Sprintf("%d", leech.Lapses), state}
+		if !compact {
+			note := ""
+			if leech.Note != "" {
+				note = "✎"
+			}
+			rows[i] = []string{rows[i][0], pronunciation(leech.Word), leech.Word.EnglishPrimary, rows[i][1], rows[i][2], note}
+		}
+	}
+	headers := []string{"Word", "Lapses", "State"}
+	if !compact {
+		headers = []string{"Word", "Pronunciation", "Translation", "Lapses", "State", "Note"}
+	}
+
+	// The selected word's note goes under the table
+	note := ""
+	if text := m.leeches[m.cursor].Note; text != "" {
+		style := lipgloss.NewStyle().Foreground(assets.Active.Accent)
+		if m.size.width > 0 {
+			style = style.Width(m.size.width)
+		}
+		note = "\n" + style.Render("Note: "+strings.ReplaceAll(text, "\n", " "))
+	}
+
+	// Borders, header and the line under it, then the title, note and help
+	available := 0
+	if m.size.height > 0 {
+		available = max(m.size.height-4-lipgloss.Height(title)-lipgloss.Height(note)-lipgloss.Height(helpView)-1, 1)
+	}
+	start, end := visibleRange(m.cursor, len(rows), available)
+	leeches := m.leeches[start:end]
+	rows = rows[start:end]
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(assets.Active.Primary).Bold(true)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			switch {
+			case row == table.HeaderRow:
+				return style.Foreground(assets.Active.Primary).Bold(true)
+			case leeches[row].Suspended:
+				return style.Foreground(assets.Active.Muted)
+			}
+			return style.Foreground(assets.Active.Accent)
+		}).
+		Headers(headers...).
+		Rows(rows...)
+	if m.size.width > 0 {
+		t = t.Width(min(lipgloss.Width(t.Render()), m.size.width))
+	}
+
+	return title + "\n" + t.Render() + note + "\n" + helpView + "\n"
+}
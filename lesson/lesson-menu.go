@@ -3,12 +3,16 @@ package lesson
 import (
 	"fmt"
 	"log"
-	"strings"
+	"slices"
+	"time"
 
 	"github.com/decarlec/lomo/assets"
 	"github.com/decarlec/lomo/messages"
 	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/speech"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
@@ -16,40 +20,137 @@ import (
 
 // MenuModel displays a list of lessons
 type LessonMenuModel struct {
-	lessons []models.Lesson
-	histories []models.History
-	cursor  int
+	lessons []models.LessonProgress
+	sortBy  lessonSort
+	filter  lessonFilter
+	cursor  int // index into visible()
+	help    help.Model
+	size    size
+	fresh   bool // just loaded, entering it the first time doesn't need to load it again
+}
+
+// How the lessons are ordered, cycled through with the sort key
+type lessonSort int
+
+const (
+	sortById lessonSort = iota
+	sortByLastStudied
+	sortByMastery
+	sortByDue
+	lessonSorts // number of sorts
+)
+
+func (s lessonSort) String() string {
+	return [...]string{"lesson", "last studied", "mastery", "due reviews"}[s]
+}
+
+// Which lessons are shown, cycled through with the filter key
+type lessonFilter int
+
+const (
+	filterAll lessonFilter = iota
+	filterNotStarted
+	filterInProgress
+	filterDue
+	filterMastered
+	lessonFilters // number of filters
+)
+
+func (f lessonFilter) String() string {
+	return [...]string{"all", "not started", "in progress", "due for review", "mastered"}[f]
+}
+
+func (f lessonFilter) matches(p models.LessonProgress) bool {
+	switch f {
+	case filterNotStarted:
+		return !p.Started()
+	case filterInProgress:
+		return p.Started() && p.Mastered < p.Total
+	case filterDue:
+		return p.Due > 0
+	case filterMastered:
+		return p.Complete()
+	}
+	return true
 }
 
 var (
-    headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(assets.Purple)).Bold(true).Align(lipgloss.Center)
     cellStyle    = lipgloss.NewStyle().Padding(0, 1).Width(10).Bold(true).AlignHorizontal(lipgloss.Center)
-    rowStyle  = cellStyle.Foreground(assets.Orange)
 )
 
-// NewMenuModel creates a MenuModel with lessons from the database
-func NewLessonMenuModel() (*LessonMenuModel, tea.Cmd) {
+// Styles using theme colours are made when drawing, the theme is picked after startup
+func headerStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(assets.Active.Primary).Bold(true).Align(lipgloss.Center)
+}
+
+func rowStyle() lipgloss.Style {
+	return cellStyle.Foreground(assets.Active.Accent)
+}
+
+// NewMenuModel creates a MenuModel with lessons and their progress from the database
+func NewLessonMenuModel() (*LessonMenuModel, error) {
 	lessons, err := models.GetAllLessons()
-	histories := []models.History{}
-	for _, lesson := range lessons {
-		lessonHistories, err := models.GetHistoryForLesson(lesson.Id)	
-		if err != nil {
-			log.Fatalf("Error fetching history for lesson %d: %v\n", lesson.Id, err)
-		}
-		var newest models.History
-		for _, history := range lessonHistories {
-			if history.CreatedAt.After(newest.CreatedAt) {
-				newest = history
-			}
-		}
-		histories = append(histories, newest)
+	if err != nil {
+		return nil, err
 	}
-
+	words, err := models.GetWordProgress(1)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := models.GetLessonProgress(lessons, words, time.Now())
 	if err != nil {
-		log.Fatalf("Error fetching lessons: %v\n", err)
+		return nil, err
+	}
+	if !Settings.UnlockAll {
+		models.Unlock(progress, Settings.UnlockMastery)
 	}
+
 	log.Printf("Fetched %d lessons from database\n", len(lessons))
-	return &LessonMenuModel{lessons: lessons }, nil
+	return &LessonMenuModel{lessons: progress, help: newHelp(), fresh: true}, nil
+}
+
+// visible is the lessons matching the filter, in sort order
+func (m LessonMenuModel) visible() []models.LessonProgress {
+	lessons := slices.DeleteFunc(slices.Clone(m.lessons), func(p models.LessonProgress) bool {
+		return !m.filter.matches(p)
+	})
+	switch m.sortBy {
+	case sortByLastStudied:
+		// Most recent first
+		slices.SortStableFunc(lessons, func(a, b models.LessonProgress) int { return b.LastStudied.Compare(a.LastStudied) })
+	case sortByMastery:
+		// Least mastered first, they need the most work
+		slices.SortStableFunc(lessons, func(a, b models.LessonProgress) int { return a.Mastery() - b.Mastery() })
+	case sortByDue:
+		slices.SortStableFunc(lessons, func(a, b models.LessonProgress) int { return b.Due - a.Due })
+	}
+	return lessons
+}
+
+// selected is the id of the lesson under the cursor, 0 if there are none
+func (m LessonMenuModel) selected() int64 {
+	lessons := m.visible()
+	if len(lessons) == 0 {
+		return 0
+	}
+	return lessons[m.cursor].Lesson.Id
+}
+
+// previous is the lesson studied before the lesson with id, the one that unlocks it
+func (m LessonMenuModel) previous(id int64) int64 {
+	var previous int64
+	for _, p := range m.lessons {
+		if p.Lesson.Id < id && p.Lesson.Id > previous {
+			previous = p.Lesson.Id
+		}
+	}
+	return previous
+}
+
+// moveTo puts the cursor on the lesson with id, or the first lesson if it isn't shown
+func (m LessonMenuModel) moveTo(id int64) LessonMenuModel {
+	m.cursor = max(slices.IndexFunc(m.visible(), func(p models.LessonProgress) bool { return p.Lesson.Id == id }), 0)
+	return m
 }
 
 // MenuModel methods
@@ -57,29 +158,87 @@ func (m LessonMenuModel) Init() tea.Cmd {
 	return nil
 }
 
+// Enter reloads the lessons, progress changes while they are studied. A menu that was just
+// loaded is shown as it is.
+func (m LessonMenuModel) Enter() (tea.Model, tea.Cmd) {
+	if m.fresh {
+		m.fresh = false
+		return m, nil
+	}
+	menu, err := NewLessonMenuModel()
+	if err != nil {
+		// Keep showing what we had
+		return m, func() tea.Msg {
+			return messages.ToastMsg{Text: fmt.Sprintf("Couldn't refresh lesson progress: %v", err)}
+		}
+	}
+	menu.sortBy = m.sortBy
+	menu.filter = m.filter
+	menu.help = m.help
+	menu.size = m.size
+	menu.fresh = false
+	return menu.moveTo(m.selected()), nil
+}
+
 func (m LessonMenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		m.help.Width = msg.Width
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, Keys.Quit, Keys.ForceQuit):
 			return m, tea.Quit
-		case "esc":
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, Keys.Back):
 			return m, func() tea.Msg {
-				log.Printf("Switching to lesson %d\n", m.lessons[m.cursor].Id)
-					return messages.SwitchToMenuMsg{}
+				log.Printf("Going back to main menu\n")
+					return messages.BackMsg{}
 				}
-		case "up", "k":
+		case key.Matches(msg, Keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
-			if m.cursor < len(m.lessons)-1 {
+		case key.Matches(msg, Keys.Down):
+			if m.cursor < len(m.visible())-1 {
 				m.cursor++
 			}
-		case "enter":
+		case key.Matches(msg, Keys.Sort):
+			id := m.selected()
+			m.sortBy = (m.sortBy + 1) % lessonSorts
+			return m.moveTo(id), nil
+		case key.Matches(msg, Keys.Filter):
+			id := m.selected()
+			m.filter = (m.filter + 1) % lessonFilters
+			return m.moveTo(id), nil
+		case key.Matches(msg, Keys.Select, Keys.Timed, Keys.Dictation):
+			lessonId := m.selected()
+			if lessonId == 0 {
+				return m, nil
+			}
+			if m.visible()[m.cursor].Locked {
+				text := fmt.Sprintf("Lesson %d is locked, master %d%% of lesson %d to unlock it", lessonId, Settings.UnlockMastery, m.previous(lessonId))
+				return m, func() tea.Msg { return messages.ToastMsg{Text: text} }
+			}
+			if key.Matches(msg, Keys.Dictation) {
+				if !speech.Enabled(Voice) {
+					return m, func() tea.Msg { return messages.ToastMsg{Text: noVoice} }
+				}
+				return m, func() tea.Msg {
+					log.Printf("Starting a dictation of lesson %d\n", lessonId)
+					return messages.SwitchToLessonMsg{LessonId: lessonId, Dictation: true}
+				}
+			}
+			if key.Matches(msg, Keys.Timed) {
+				return m, func() tea.Msg {
+					log.Printf("Starting a timed run of lesson %d\n", lessonId)
+					return messages.SwitchToLessonMsg{LessonId: lessonId, Timed: true}
+				}
+			}
 			return m, func() tea.Msg {
-				log.Printf("Switching to lesson %d\n", m.lessons[m.cursor].Id)
-					return messages.SwitchToLessonMsg{LessonId: m.lessons[m.cursor].Id}
+				log.Printf("Switching to lesson %d\n", lessonId)
+					return messages.SwitchToLessonMsg{LessonId: lessonId}
 				}
 		}
 	}
@@ -87,37 +246,103 @@ func (m LessonMenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m LessonMenuModel) View() string {
-	rows := make([][]string, len(m.lessons))
-	for lessonIndex, lesson := range m.lessons {
+	now := time.Now()
+	lessons := m.visible()
+	rows := make([][]string, len(lessons))
+	for lessonIndex, p := range lessons {
 		cursor := "  "
 		if m.cursor == lessonIndex {
 			cursor = "=>"
 		}
-		var history models.History
-		for _, h := range m.histories {
-			if h.LessonId == lesson.Id {
-				history = h
-				log.Printf("Found history for lesson %d: %v\n", lesson.Id, history)
-			}
+		rows[lessonIndex] = []string{
+			fmt.Sprintf("%s %d", cursor, p.Lesson.Id),
+			fmt.Sprintf("%d/%d", p.Correct, p.Total),
+			fmt.Sprintf("%d/%d", p.Best, p.Total),
+			fmt.Sprintf("%d%%", p.Mastery()),
+			fmt.Sprintf("%d", p.Due),
+			studiedAgo(p.LastStudied, now),
+			state(p),
+		}
+	}
+	headers := []string{"Lesson", "Progress", "Best", "Mastery", "Due", "Studied", "State"}
+	// Each cell and the border after it, then the left border
+	if tableWidth := len(headers)*(cellStyle.GetWidth()+1) + 1; m.size.width > 0 && m.size.width < tableWidth {
+		// Lesson, progress, mastery and state
+		headers = []string{headers[0], headers[1], headers[3], headers[6]}
+		for i, row := range rows {
+			rows[i] = []string{row[0], row[1], row[3], row[6]}
 		}
+	}
+
+	title := fmt.Sprintf("Sorted by %s, showing %s lessons", m.sortBy, m.filter)
+	if m.filter == filterAll {
+		title = fmt.Sprintf("Sorted by %s", m.sortBy)
+	}
+	title = headerStyle().Render(title)
+	if len(lessons) == 0 {
+		title += "\n" + lipgloss.NewStyle().Foreground(assets.Active.Accent).Render("No lessons match the filter")
+	}
 
-		rows[lessonIndex] = []string{fmt.Sprintf("%s %d", cursor, lesson.Id), fmt.Sprintf("%d/%d", len(strings.Split(history.CorrectIds, ",")), len(strings.Split(lesson.WordIDs, ",")))}
+	helpView := m.help.View(lessonMenuKeys{Keys})
+	// Borders, header and the line under it, then the help
+	available := 0
+	if m.size.height > 0 {
+		available = max(m.size.height-4-lipgloss.Height(helpView)-lipgloss.Height(title)-1, 1)
 	}
+	start, end := visibleRange(m.cursor, len(rows), available)
+	rows = rows[start:end]
+	lessons = lessons[start:end]
+
 	table := table.New().
     Border(lipgloss.RoundedBorder()).
     BorderStyle(
-			lipgloss.NewStyle().Foreground(assets.Purple).
+			lipgloss.NewStyle().Foreground(assets.Active.Primary).
 			Bold(true)).
     StyleFunc(func(row, col int) lipgloss.Style {
         switch  row {
         case table.HeaderRow:
-            return headerStyle
+            return headerStyle()
         default:
-            return rowStyle
+            if lessons[row].Locked {
+                return rowStyle().Foreground(assets.Active.Muted)
+            }
+            return rowStyle()
         }
     }).
-    Headers("Lesson", "Progress").
+    Headers(headers...).
     Rows(rows...).Render()
 
-		return table + "\nPress q to quit.\n"
+		return title + "\n" + table + "\n" + helpView + "\n"
+}
+
+// state is whether a lesson is locked, open or mastered
+func state(p models.LessonProgress) string {
+	switch {
+	case p.Locked:
+		return "locked"
+	case p.Complete():
+		return "mastered"
+	}
+	return "open"
+}
+
+// studiedAgo describes when a lesson was last studied, e.g. "today" or "3d ago". It's kept short
+// enough to fit a cell on one line, visibleRange counts on every row being one line tall.
+func studiedAgo(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	y, m, d := t.Local().Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	y, m, d = now.Local().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	switch days := int(today.Sub(day).Hours() / 24); {
+	case days <= 0:
+		return "today"
+	case days < 7:
+		return fmt.Sprintf("%dd ago", days)
+	case t.Local().Year() == now.Local().Year():
+		return t.Local().Format("Jan 2")
+	}
+	return t.Local().Format("Jan 2006")
 }
@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"regexp"
-	"slices"
 	"strings"
 
 	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/config"
 	"github.com/decarlec/lomo/messages"
 	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/pronounce"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -25,10 +27,16 @@ type LessonModel struct {
 	result []models.History
 	lessonType string
 	current    int
+	reversed   []bool // words asked with their translation shown, see config.Direction
+	notes      map[int64]string // the user's notes by word id, shown with the answer
+	examples   map[int64][]models.Sentence // example sentences by word id, shortest first
+	timed      timedRun // clocks and score of a timed run
+	help       help.Model
+	size       size
 }
 
-
-const SHUFFLE = false
+// Settings from the config file
+var Settings = config.Default()
 
 // Special case lesson model for Review lessons
 func NewReviewLessonModel(lesson *models.Lesson) (*LessonModel, tea.Cmd) {
@@ -45,19 +53,21 @@ func NewReviewLessonModel(lesson *models.Lesson) (*LessonModel, tea.Cmd) {
 		words:      words,
 		textInput:  ti,
 		lessonType: "review",
+		reversed:   directions(len(words)),
+		help:       newHelp(),
 	}, nil
 
 }
 
 // NewLessonModel creates a LessonModel for a given lesson
-func NewLessonModel(lessonId int64) (*LessonModel, tea.Cmd) {
+func NewLessonModel(lessonId int64) (*LessonModel, error) {
 	lesson, err := models.GetLessonByID(lessonId)
 	if err != nil {
-		log.Fatalf("Error fetching lesson by ID: %v\n", err)
+		return nil, fmt.Errorf("error fetching lesson %d: %w", lessonId, err)
 	}
 
 	words := lesson.Words
-	if SHUFFLE {
+	if Settings.Shuffle {
 		// Shuffle words
 		for i := range words {
 			j := rand.Intn(i + 1)
@@ -66,112 +76,311 @@ func NewLessonModel(lessonId int64) (*LessonModel, tea.Cmd) {
 	}
 
 	ti := getLessonInput()
-	return &LessonModel{
+	m := &LessonModel{
 		Lesson:     *lesson,
 		words:      words,
 		textInput:  ti,
 		lessonType: "normal",
-	}, nil
+		help:       newHelp(),
+	}
+
+	// Pick up where the user left off last time
+	session, err := models.GetSession(1, lessonId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved session for lesson %d: %w", lessonId, err)
+	}
+	if session != nil {
+		m.words = session.Restore(words)
+		m.Lesson.Words = m.words
+		m.current = min(session.Current, max(len(m.words)-1, 0))
+		m.textInput.SetValue(session.Input)
+	}
+	m.reversed = directions(len(m.words))
+	return m, nil
+}
+
+// directions picks which way round each of n words is asked
+func directions(n int) []bool {
+	reversed := make([]bool, n)
+	for i := range reversed {
+		switch Settings.Direction {
+		case config.Reverse:
+			reversed[i] = true
+		case config.Mixed:
+			reversed[i] = rand.Intn(2) == 1
+		}
+	}
+	return reversed
+}
+
+// recordAnswer stores an answer, used for the daily goal. Dictation answers are practice, they don't count towards progress.
+func (m LessonModel) recordAnswer(word models.Word, correct bool) tea.Cmd {
+	record := models.RecordAnswer
+	if m.IsDictation() {
+		record = models.RecordPracticeAnswer
+	}
+	return func() tea.Msg {
+		if err := record(1, m.Lesson.Id, word.Id, correct); err != nil {
+			log.Printf("Error recording answer for word %d: %v\n", word.Id, err)
+			return messages.ToastMsg{Text: "Couldn't record your answer, it won't count towards your daily goal"}
+		}
+		return nil
+	}
+}
+
+// recordPeek stores that the answer to word was shown, peeked words come up when reviewing mistakes.
+// Peeks in a dictation aren't mistakes in the lesson, they aren't stored.
+func (m LessonModel) recordPeek(word models.Word) tea.Cmd {
+	if m.IsDictation() {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := models.RecordPeek(1, m.Lesson.Id, word.Id); err != nil {
+			log.Printf("Error recording peek for word %d: %v\n", word.Id, err)
+		}
+		return nil
+	}
 }
 
 // LessonModel methods
 func (m LessonModel) Init() tea.Cmd {
+	if m.IsTimed() {
+		return tea.Batch(textinput.Blink, m.timed.tick())
+	}
 	return textinput.Blink
 }
 
+// Enter focuses the answer input again when the lesson is shown or resumed, and loads the
+// notes on its words since one may have just been edited
+func (m LessonModel) Enter() (tea.Model, tea.Cmd) {
+	cmd := tea.Batch(m.textInput.Focus(), m.announce())
+	ids := make([]int64, len(m.words))
+	for i, word := range m.words {
+		ids[i] = word.Id
+	}
+	notes, err := models.GetNotes(1, ids)
+	if err != nil {
+		log.Printf("Error fetching notes: %v\n", err)
+		return m, tea.Batch(cmd, func() tea.Msg {
+			return messages.ToastMsg{Text: "Couldn't load your notes for this lesson"}
+		})
+	}
+	m.notes = notes
+
+	// Example sentences only change when they are imported
+	if m.examples == nil {
+		examples, err := models.GetSentences(ids)
+		if err != nil {
+			log.Printf("Error fetching example sentences: %v\n", err)
+			return m, tea.Batch(cmd, func() tea.Msg {
+				return messages.ToastMsg{Text: "Couldn't load example sentences for this lesson"}
+			})
+		}
+		m.examples = examples
+	}
+	return m, cmd
+}
+
+// cloze is word's example sentence with the word blanked out, when cloze drills are on and it has one
+func (m LessonModel) cloze(word models.Word) (string, models.Sentence, bool) {
+	if !Settings.Cloze {
+		return "", models.Sentence{}, false
+	}
+	for _, sentence := range m.examples[word.Id] {
+		if text, ok := sentence.Cloze(word.Spanish); ok {
+			return text, sentence, true
+		}
+	}
+	return "", models.Sentence{}, false
+}
+
+// asksSpanish reports whether the answer to the word at index is the Spanish word, not a translation
+func (m LessonModel) asksSpanish(index int) bool {
+	_, _, cloze := m.cloze(m.words[index])
+	return m.reversed[index] || cloze || m.IsDictation()
+}
+
+// Leave stops the cursor blinking while the lesson is not shown, and saves it so it can be resumed after a restart
+func (m LessonModel) Leave() (tea.Model, tea.Cmd) {
+	m.textInput.Blur()
+	if err := m.saveSession(); err != nil {
+		log.Printf("Error saving session for lesson %d: %v\n", m.Lesson.Id, err)
+		return m, func() tea.Msg {
+			return messages.ToastMsg{Text: "Couldn't save your place in this lesson, it won't be resumable after quitting"}
+		}
+	}
+	return m, nil
+}
+
+// saveSession stores an unfinished lesson's state, or forgets it once the lesson is finished
+func (m LessonModel) saveSession() error {
+	if m.IsPractice() {
+		return nil
+	}
+	if m.Finished() {
+		return models.DeleteSession(1, m.Lesson.Id)
+	}
+	return models.SaveSession(models.NewSession(1, m.Lesson.Id, m.words, m.current, m.textInput.Value()))
+}
+
+// save records the lesson's results and session, then sends next.
+// If that fails an error is shown instead, and retrying saves again.
+func (m LessonModel) save(next tea.Cmd) tea.Cmd {
+	var cmd tea.Cmd
+	cmd = func() tea.Msg {
+		if err := models.WriteHistory(m.Lesson, 1, m.Lesson.Words); err != nil {
+			return messages.ErrorMsg{Err: fmt.Errorf("error saving results for lesson %d: %w", m.Lesson.Id, err), Retry: cmd}
+		}
+		if err := m.saveSession(); err != nil {
+			return messages.ErrorMsg{Err: fmt.Errorf("error saving session for lesson %d: %w", m.Lesson.Id, err), Retry: cmd}
+		}
+		return next()
+	}
+	return cmd
+}
+
+func (m LessonModel) IsReview() bool {
+	return m.lessonType == "review"
+}
+
+func (m LessonModel) IsTimed() bool {
+	return m.lessonType == "timed"
+}
+
+// IsPractice reports whether the lesson is studied without saving its results or place, as
+// reviews, timed runs and dictations are
+func (m LessonModel) IsPractice() bool {
+	return m.IsReview() || m.IsTimed() || m.IsDictation()
+}
+
+// Finished reports whether every word in the lesson has been answered correctly
+func (m LessonModel) Finished() bool {
+	return len(m.words) > 0 && getNumCorrect(m.words) == len(m.words)
+}
+
+// offersMistakes reports whether the lesson's mistakes can be gone over, once a normal lesson is finished.
+// Reviews are already going over mistakes and dictations don't record any.
+func (m LessonModel) offersMistakes() bool {
+	return m.lessonType == "normal" && m.Finished()
+}
+
 func (m LessonModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	log.Printf("Updating lessonmodel")
 
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.size = newSize(msg)
+		_, horizontal := m.size.padding()
+		m.help.Width = m.size.cardWidth() - horizontal
+		// Leave room for the prompt and cursor
+		m.textInput.Width = max(min(Settings.InputWidth, m.size.cardWidth()-horizontal-4), 1)
+		return m, nil
+	}
+
+	// Nothing to study, all that can be done is leave
+	if len(m.words) == 0 {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, Keys.Back, Keys.ForceQuit) {
+			return m, func() tea.Msg { return messages.BackMsg{} }
+		}
+		return m, nil
+	}
+
+	if m.IsTimed() {
+		return m.updateTimed(msg)
+	}
+
 	var currentWord = &m.words[m.current]
+	var record tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 
-		if msg.String() == "/" {
+		switch {
+		case key.Matches(msg, Keys.Peek):
 			currentWord.Peek = !currentWord.Peek
+			// Only the first peek at a word counts, toggling it back on isn't another mistake
+			if currentWord.Peek && !currentWord.Correct && !currentWord.Peeked {
+				currentWord.Peeked = true
+				return m, m.recordPeek(*currentWord)
+			}
 			return m, nil
-		}
-		switch msg.Type {
-		case tea.KeyCtrlC:
-			err := models.WriteHistory(m.Lesson, 1, m.Lesson.Words)
-			if err != nil {
-				log.Fatal(err)
+		case key.Matches(msg, Keys.Note):
+			wordId := currentWord.Id
+			return m, func() tea.Msg {
+				return messages.SwitchToNoteMsg{WordId: wordId}
+			}
+		case key.Matches(msg, Keys.Mistakes):
+			if !m.offersMistakes() {
+				return m, nil
 			}
-			return m, tea.Quit
+			lessonId := m.Lesson.Id
+			return m, func() tea.Msg {
+				log.Printf("Reviewing mistakes from lesson %d\n", lessonId)
+				return messages.SwitchToMistakesMsg{LessonId: lessonId}
+			}
+		case key.Matches(msg, Keys.Speak):
+			return m, m.speakWord()
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m, nil
+		case key.Matches(msg, Keys.ForceQuit):
+			if m.IsPractice() {
+				return m, tea.Quit
+			}
+			return m, m.save(tea.Quit)
 
 		//Scroll words
-		case tea.KeyRight:
+		case key.Matches(msg, Keys.Next):
 			if m.current < len(m.words)-1 {
 				m.current++
 				m.textInput.SetValue("")
-				return m, nil
+				return m, m.announce()
 			}
 		//Scroll words
-		case tea.KeyLeft:
+		case key.Matches(msg, Keys.Prev):
 			if m.current > 0 {
 				m.current--
-				return m, nil
+				return m, m.announce()
 			}
 		//Go back
-		case tea.KeyEsc:
-			if m.lessonType == "review" {
+		case key.Matches(msg, Keys.Back):
+			if m.IsPractice() {
 			return m, func() tea.Msg {
-				log.Printf("Switching to main menu\n")
-					return messages.SwitchToMenuMsg{}
+				log.Printf("Leaving %s\n", m.lessonType)
+					return messages.BackMsg{}
 				}
 			}
-			err := models.WriteHistory(m.Lesson, 1, m.Lesson.Words)
-			if err != nil {
-				log.Fatal(err)
+			return m, m.save(func() tea.Msg {
+				log.Printf("Leaving lesson %d\n", m.Lesson.Id)
+					return messages.BackMsg{}
+				})
+		case key.Matches(msg, Keys.Submit):
+			// Already answered, grading it again would record the same answer again
+			if currentWord.Correct {
+				return m, nil
 			}
-			return m, func() tea.Msg {
-				log.Printf("Switching to lesson menu\n")
-					return messages.SwitchToLessonMenuMsg{}
-				}
-		case tea.KeyEnter:
-			if m.textInput.Value() == currentWord.EnglishPrimary || checkWord(m.textInput.Value(), currentWord.English_Translations) {
-				currentWord.Correct = true
+			currentWord.Correct = grade(m.textInput.Value(), *currentWord, m.asksSpanish(m.current), Settings.Grading)
+			if strings.TrimSpace(m.textInput.Value()) != "" {
+				record = m.recordAnswer(*currentWord, currentWord.Correct)
+			}
+			if currentWord.Correct {
 				m.textInput.Placeholder = ""
 			} else {
-				currentWord.Correct = false
-				m.textInput.Placeholder = "try again!"
-				m.textInput.PlaceholderStyle.Foreground(lipgloss.Color(assets.Input_wrong))
+				currentWord.Missed = strings.TrimSpace(m.textInput.Value()) != "" || currentWord.Missed
+				m.textInput.Placeholder = "✗ try again!"
+				m.textInput.PlaceholderStyle.Foreground(assets.Active.Wrong)
 				m.textInput.SetValue("")
 			}
 		}
 
 		//handle actual text input
 		m.textInput, cmd = m.textInput.Update(msg)
-		return m, cmd
+		return m, tea.Batch(cmd, record)
 	}
 	return m, nil
 }
 
-func checkWord(input string, translations []string) bool {
-	if input == "" {
-		return false
-	}
-	//removes all brakets "({[" and their contents
-	re := regexp.MustCompile(`[\(\{\[].*?[\}\)\]]`)
-	return slices.Contains(translations, input) || slices.ContainsFunc(translations, func(translation string) bool {
-		//remove any bracketed descriptive text.
-		trimmed := re.ReplaceAllString(translation, "")
-		//Split any different words in the section
-		for section := range strings.SplitSeq(trimmed, " ") {
-			//remove any leading and trailing punctuation from the words
-			noComma := strings.Trim(section, ",;. ")
-			if noComma == input {
-				log.Printf("Matched on '%s' for input string of '%s' and translations of '%s'", noComma, input, strings.Join(translations, "<<<>>>"))
-				//we have a match
-				return true
-			}
-		}
-		return false
-	})
-}
-
 func getNumCorrect(words []models.Word) int {
 	num := 0
 	for _, word := range words {
@@ -185,42 +394,84 @@ func getNumCorrect(words []models.Word) int {
 
 func (m LessonModel) View() string {
 	if len(m.words) == 0 {
-		return "No words in this lesson.\nPress b to go back.\n"
+		return "No words in this lesson.\nPress Esc to go back.\n"
+	}
+
+	if m.IsTimed() {
+		return m.timedView()
 	}
 
 	word := m.words[m.current]
 	s := ""
 	//Title bar
-	if m.lessonType == "review" {
+	if m.IsReview() {
 		s += fmt.Sprintf("Review - Word %d/%d\n\n", getNumCorrect(m.words), len(m.words))
+	} else if m.IsDictation() {
+		s += fmt.Sprintf("Dictation - Lesson %d - Word %d/%d\n\n", m.Lesson.Id, getNumCorrect(m.words), len(m.words))
 	} else {
 		s += fmt.Sprintf("Lesson %d - Word %d/%d\n\n", m.Lesson.Id, getNumCorrect(m.words), len(m.words))
 	}
+	if m.offersMistakes() {
+		s += lipgloss.NewStyle().Foreground(assets.Active.Correct).Render(
+			fmt.Sprintf("✓ All done! Press %s to go over your mistakes.", Keys.Mistakes.Help().Key)) + "\n\n"
+	} else if m.Finished() {
+		s += lipgloss.NewStyle().Foreground(assets.Active.Correct).Render("✓ All done!") + "\n\n"
+	}
 
-	// Word display
-	s += "Spanish: "
-	s += lipgloss.NewStyle().Bold(true).UnsetPadding().Foreground(assets.Cyan).Render(word.Spanish)
-	s += "\n"
+	examples := m.examples[word.Id]
+	reversed := m.asksSpanish(m.current)
+	s += m.prompt(m.current)
 	s += m.textInput.View() + "\n"
 
 	// Results
 	if word.Correct {
-		s += correctStyle("Correct! \n" + translation(word))
+		s += correctStyle("✓ Correct! \n" + answer(word, reversed) + example(examples), m.size)
 	} else if word.Peek {
-		s += peekStyle(translation(word))
+		s += peekStyle("? Answer shown \n" + answer(word, reversed) + example(examples), m.size)
+	}
+	// Notes help most when the answer didn't come
+	if note := m.notes[word.Id]; note != "" && (word.Peek || word.Missed) {
+		s += "\n" + lipgloss.NewStyle().UnsetBold().Foreground(assets.Active.Accent).Render("✎ Note: "+note) + "\n"
 	}
 
 	//Help text
-	s += lipgloss.NewStyle().PaddingTop(1).UnsetBold().Render("\nPress the / key to see answer, left/right to navigate, Esc go back, Ctrl+C to quit.")
-	return lessonStyle(s)
+	s += lipgloss.NewStyle().PaddingTop(1).UnsetBold().Render("\n" + m.help.View(lessonKeys{Keys, m.offersMistakes()}))
+	return lessonStyle(s, m.size)
+}
+
+// prompt shows what is asked for the word at index, its Spanish, its translation or a sentence to fill in
+func (m LessonModel) prompt(index int) string {
+	word := m.words[index]
+	s := ""
+	wordStyle := lipgloss.NewStyle().Bold(true).UnsetPadding().Foreground(assets.Active.Secondary)
+	exampleStyle := lipgloss.NewStyle().UnsetBold().Foreground(assets.Active.Muted)
+	examples := m.examples[word.Id]
+	reversed := m.asksSpanish(index)
+	if m.IsDictation() {
+		s += "Listen: " + wordStyle.Render("type the Spanish word you hear") +
+			exampleStyle.Render(fmt.Sprintf(" (%s to hear it again)", Keys.Speak.Help().Key))
+	} else if text, sentence, ok := m.cloze(word); ok {
+		s += "Fill in: " + wordStyle.Render(text) + "\n"
+		s += exampleStyle.Render(sentence.English)
+	} else if reversed {
+		s += "English: " + wordStyle.Render(word.EnglishPrimary)
+	} else {
+		s += "Spanish: " + wordStyle.Render(word.DisplaySpanish())
+		s += "\n" + exampleStyle.Render(pronunciation(word))
+		if len(examples) > 0 {
+			// The translation would give the answer away, it comes with the answer
+			s += "\n" + exampleStyle.Render(examples[0].Spanish)
+		}
+	}
+	return s + "\n"
 }
 
 func getLessonInput() textinput.Model {
 	ti := textinput.New()
 	ti.Focus()
 	ti.CharLimit = 156
-	ti.Width = 10
-	var inputStyle = lipgloss.NewStyle().Foreground(assets.Orange).Background(assets.Bg)
+	ti.Width = Settings.InputWidth
+	var inputStyle = lipgloss.NewStyle().Foreground(assets.Active.Accent).Background(assets.Active.Background)
 	ti.PromptStyle = inputStyle
 	ti.TextStyle = inputStyle
 	ti.Cursor.Style = inputStyle
@@ -228,43 +479,71 @@ func getLessonInput() textinput.Model {
 	return ti
 }
 
+// answer shows the answer to word, asked the way round given by reversed
+func answer(word models.Word, reversed bool) string {
+	if reversed {
+		return fmt.Sprintf("Spanish: %s\n%s\n\n%s", word.DisplaySpanish(), pronunciation(word), translation(word))
+	}
+	return translation(word)
+}
+
+// example shows the first of a word's example sentences with its translation
+func example(examples []models.Sentence) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nExample: %s\n\t%s", examples[0].Spanish, examples[0].English)
+}
+
+// pronunciation shows how a word is said, split into syllables with the stressed one in capitals, then in IPA
+func pronunciation(word models.Word) string {
+	return fmt.Sprintf("%s /%s/", pronounce.Guide(word.Spanish), pronounce.IPA(word.Spanish))
+}
+
 func translation(word models.Word) string {
-	return fmt.Sprintf("Translation: %s \n\nOther translations:\n\t%s", word.EnglishPrimary, strings.Join(word.English_Translations, "\n\t"))
+	others := make([]string, len(word.Senses))
+	for i, sense := range word.Senses {
+		others[i] = sense.String()
+	}
+	return fmt.Sprintf("Translation: %s \n\nOther translations:\n\t%s", word.EnglishPrimary, strings.Join(others, "\n\t"))
 }
 
-func lessonStyle(view string) string {
+func lessonStyle(view string, size size) string {
+	vertical, horizontal := size.padding()
 	var style = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color(assets.Purple)).
-		Background(lipgloss.Color(assets.Bg)).
-		PaddingTop(2).
-		PaddingBottom(2).
-		PaddingLeft(4).
-		Width(100).
+		Foreground(assets.Active.Primary).
+		Background(assets.Active.Background).
+		PaddingTop(vertical).
+		PaddingBottom(vertical).
+		PaddingLeft(horizontal).
+		Width(size.cardWidth()).
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color(assets.Purple)).
-		BorderBackground(lipgloss.Color(assets.Bg))
+		BorderForeground(assets.Active.Primary).
+		BorderBackground(assets.Active.Background)
 	return style.Render(view)
 }
 
-func correctStyle(view string) string {
+func correctStyle(view string, size size) string {
+	vertical, _ := size.padding()
 	var style = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color(assets.Green)).
-		Background(assets.Bg).
-		PaddingTop(2).
-		PaddingBottom(2)
+		Foreground(assets.Active.Correct).
+		Background(assets.Active.Background).
+		PaddingTop(max(vertical, 1)).
+		PaddingBottom(vertical)
 
 	return style.Render(view)
 }
 
-func peekStyle(view string) string {
+func peekStyle(view string, size size) string {
+	vertical, _ := size.padding()
 	var style = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color(assets.Orange)).
-		Background(assets.Bg).
-		PaddingTop(2).
-		PaddingBottom(2)
+		Foreground(assets.Active.Accent).
+		Background(assets.Active.Background).
+		PaddingTop(max(vertical, 1)).
+		PaddingBottom(vertical)
 
 	return style.Render(view)
 }
@@ -0,0 +1,90 @@
+package lesson
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPeekRecordsOnce(t *testing.T) {
+	peek := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")}
+	var m tea.Model = newTestLesson("normal", false)
+
+	var recorded int
+	for range 3 {
+		var cmd tea.Cmd
+		m, cmd = m.Update(peek) // on
+		if cmd != nil {
+			recorded++
+		}
+		m, _ = m.Update(peek) // off
+	}
+	if recorded != 1 {
+		t.Errorf("peeking 3 times recorded %d peeks, want 1", recorded)
+	}
+}
+
+func TestSubmitRecordsOnce(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	lesson := newTestLesson("normal", false)
+	lesson.textInput = getLessonInput()
+	lesson.textInput.SetValue("house")
+
+	m, cmd := lesson.Update(enter)
+	if !m.(LessonModel).words[0].Correct || cmd == nil {
+		t.Fatalf("submitting the answer gave correct %v and command %v, want it graded and recorded", m.(LessonModel).words[0].Correct, cmd)
+	}
+	for range 2 {
+		if m, cmd = m.Update(enter); cmd != nil {
+			t.Errorf("submitting an answered word again returned %v, want nothing recorded", cmd)
+		}
+	}
+}
+
+func TestOffersMistakes(t *testing.T) {
+	tests := []struct {
+		lessonType string
+		want       bool
+	}{
+		{"normal", true},
+		{"review", false},
+		{"dictation", false},
+	}
+	for _, tt := range tests {
+		m := newTestLesson(tt.lessonType, false)
+		if m.offersMistakes() {
+			t.Errorf("unfinished %s lesson offers mistakes", tt.lessonType)
+		}
+		m.words[0].Correct = true
+		if got := m.offersMistakes(); got != tt.want {
+			t.Errorf("finished %s lesson offersMistakes() = %v, want %v", tt.lessonType, got, tt.want)
+		}
+	}
+}
+
+func TestStudiedAgoFitsACell(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		studied time.Time
+		want    string
+	}{
+		{time.Time{}, "never"},
+		{now.Add(-time.Hour), "today"},
+		{now.AddDate(0, 0, -1), "1d ago"},
+		{now.AddDate(0, 0, -6), "6d ago"},
+		{now.AddDate(0, 0, -30), "Feb 8"},
+		{now.AddDate(-1, 0, 0), "Mar 2025"},
+	}
+	for _, tt := range tests {
+		got := studiedAgo(tt.studied, now)
+		if got != tt.want {
+			t.Errorf("studiedAgo(%v) = %q, want %q", tt.studied, got, tt.want)
+		}
+		if height := lipgloss.Height(rowStyle().Render(got)); height != 1 {
+			t.Errorf("studiedAgo(%v) = %q is %d lines tall in a cell, want 1", tt.studied, got, height)
+		}
+	}
+}
@@ -3,11 +3,15 @@ package lesson
 import (
 	"fmt"
 	"log"
+	"math/rand"
 
 	"github.com/decarlec/lomo/assets"
 	"github.com/decarlec/lomo/db"
 	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	_ "github.com/mattn/go-sqlite3"
@@ -17,7 +21,24 @@ type MainMenuModel struct {
 	Choices  []string         // lesson ids
 	cursor   int              // which to-do list item our cursor is pointing at
 	Selected map[int]struct{} // which to-do items are selected
-	Logo string	
+	logo     int // which of the logos that fit to show, picked at random
+	Resume *models.Session // most recent unfinished lesson, offered above the other choices
+	CorrectToday int // words answered correctly today, for the daily goal
+	help   help.Model
+	size   size
+}
+
+func NewMainMenuModel() MainMenuModel {
+	return MainMenuModel{
+		Choices: []string{"Lessons", "Review", "Mistakes", "Leeches", "Conjugation", "Gender", "Leaderboard"},
+
+		// A map which indicates which choices are selected. We're using
+		// the  map like a mathematical set. The keys refer to the indexes
+		// of the `choices` slice, above.
+		Selected: make(map[int]struct{}),
+		logo:     rand.Int(),
+		help:     newHelp(),
+	}
 }
 
 func (m MainMenuModel) Init() tea.Cmd {
@@ -25,39 +46,110 @@ func (m MainMenuModel) Init() tea.Cmd {
 	return nil
 }
 
+// Enter refreshes the resume choice and daily goal, they change as lessons are studied
+func (m MainMenuModel) Enter() (tea.Model, tea.Cmd) {
+	return m.LoadResume().LoadDailyGoal(), nil
+}
+
+// LoadResume looks up the most recent unfinished lesson to offer resuming it
+func (m MainMenuModel) LoadResume() MainMenuModel {
+	session, err := models.GetLatestSession(1)
+	if err != nil {
+		log.Printf("Error fetching latest session: %v\n", err)
+	}
+	m.Resume = session
+	m.cursor = min(m.cursor, len(m.items())-1)
+	return m
+}
+
+// LoadDailyGoal counts the words answered correctly today
+func (m MainMenuModel) LoadDailyGoal() MainMenuModel {
+	count, err := models.CountCorrectToday(1)
+	if err != nil {
+		log.Printf("Error counting today's answers: %v\n", err)
+	}
+	m.CorrectToday = count
+	return m
+}
+
+// items are the rows shown in the menu, the resume choice followed by Choices
+func (m MainMenuModel) items() []string {
+	if m.Resume == nil {
+		return m.Choices
+	}
+	return append([]string{m.Resume.String()}, m.Choices...)
+}
+
 func (m MainMenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		m.help.Width = msg.Width
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, Keys.Quit, Keys.ForceQuit):
 			db.DB.Close()
 			return m, tea.Quit
-		case "up", "k":
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, Keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
-			if m.cursor < len(m.Choices)-1 {
+		case key.Matches(msg, Keys.Down):
+			if m.cursor < len(m.items())-1 {
 				m.cursor++
 			}
-		case "enter", " ":
+		case key.Matches(msg, Keys.Select):
 			_, ok := m.Selected[m.cursor]
 			if ok {
 				delete(m.Selected, m.cursor)
 			} else {
 				m.Selected[m.cursor] = struct{}{}
 			}
-			switch m.cursor {
-			case 0:
+			if m.Resume != nil && m.cursor == 0 {
+				lessonId := m.Resume.LessonId
+				return m, func() tea.Msg {
+					log.Printf("Resuming lesson %d\n", lessonId)
+					return messages.SwitchToLessonMsg{LessonId: lessonId}
+				}
+			}
+			switch m.items()[m.cursor] {
+			case "Lessons":
 				return m, func() tea.Msg {
 					log.Printf("Switching to lesson menu\n")
 					return messages.SwitchToLessonMenuMsg{}
 				}
-			case 1:
+			case "Review":
 				return m, func() tea.Msg {
 					log.Printf("Switching to Review Lesson\n")
 					return messages.SwitchToReviewMsg{}
 				}
+			case "Mistakes":
+				return m, func() tea.Msg {
+					log.Printf("Switching to mistakes review\n")
+					return messages.SwitchToMistakesMsg{}
+				}
+			case "Leeches":
+				return m, func() tea.Msg {
+					log.Printf("Switching to leeches\n")
+					return messages.SwitchToLeechesMsg{}
+				}
+			case "Conjugation":
+				return m, func() tea.Msg {
+					log.Printf("Switching to conjugation drill\n")
+					return messages.SwitchToConjugationMsg{}
+				}
+			case "Gender":
+				return m, func() tea.Msg {
+					log.Printf("Switching to gender drill\n")
+					return messages.SwitchToGenderMsg{}
+				}
+			case "Leaderboard":
+				return m, func() tea.Msg {
+					log.Printf("Switching to leaderboard\n")
+					return messages.SwitchToLeaderboardMsg{}
+				}
 			}
 
 		}
@@ -67,20 +159,23 @@ func (m MainMenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m MainMenuModel) View() string {
 	// The header
-	var welcome = lipgloss.NewStyle().Foreground(lipgloss.Color(assets.Orange)).Bold(true).Align(lipgloss.Center).Render(
+	var welcome = lipgloss.NewStyle().Foreground(assets.Active.Accent).Bold(true).Align(lipgloss.Center).Render(
 `Welcome to
 `)
 
-	var message = lipgloss.NewStyle().Foreground(lipgloss.Color(assets.Orange)).Bold(true).Align(lipgloss.Center).Render(
+	var message = lipgloss.NewStyle().Foreground(assets.Active.Accent).Bold(true).Align(lipgloss.Center).Render(
 `
 
 	...the language learning TUI (too-ee) app!
 `)
 
-	s := welcome + m.Logo + message + "\n"
+	s := welcome + m.logoView() + message + "\n"
+	if m.size.compact() {
+		s = lipgloss.NewStyle().Foreground(assets.Active.Primary).Render("lomo") + "\n\n"
+	}
 
 	// Iterate over our choices
-	for i, choice := range m.Choices {
+	for i, choice := range m.items() {
 		cursor := "  "
 		if m.cursor == i {
 			cursor = "=>"
@@ -89,25 +184,50 @@ func (m MainMenuModel) View() string {
 		s += fmt.Sprintf("%s %s\n", cursor, choice)
 	}
 
+	if Settings.DailyGoal > 0 {
+		goal := fmt.Sprintf("Daily goal: %d/%d words", m.CorrectToday, Settings.DailyGoal)
+		if m.CorrectToday >= Settings.DailyGoal {
+			goal = fmt.Sprintf("Daily goal reached! %d/%d words", m.CorrectToday, Settings.DailyGoal)
+		}
+		s += "\n" + lipgloss.NewStyle().Foreground(assets.Active.Correct).Render(goal) + "\n"
+	}
+
 		// Render the row
 
+	vertical, horizontal := m.size.padding()
 	var style = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color(assets.Cyan)).
-		PaddingTop(2).
-		PaddingLeft(4).
-		Width(100).
+		Foreground(assets.Active.Secondary).
+		PaddingTop(vertical).
+		PaddingLeft(horizontal).
+		Width(m.size.cardWidth()).
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color(assets.Purple))
+		BorderForeground(assets.Active.Primary)
 
 
 	s = style.Render(s)
 
-	var exit = "\nPress q to quit.\n"
-
-	s += exit
+	s += "\n" + m.help.View(mainMenuKeys{Keys}) + "\n"
 
 	// Send the UI for rendering
 	return s
 }
 
+// logoView picks a logo that fits in the terminal, or just the name if none do
+func (m MainMenuModel) logoView() string {
+	_, horizontal := m.size.padding()
+	width := m.size.cardWidth() - horizontal
+	// Room left once the rest of the menu is drawn
+	height := m.size.height - len(m.items()) - 16
+	fits := []string{}
+	for _, logo := range assets.Logos {
+		if lipgloss.Width(logo) <= width && (m.size.height == 0 || lipgloss.Height(logo) <= height) {
+			fits = append(fits, logo)
+		}
+	}
+	style := lipgloss.NewStyle().Foreground(assets.Active.Primary).Bold(true).Align(lipgloss.Center)
+	if len(fits) == 0 {
+		return style.Render("\nlomo")
+	}
+	return style.Render(fits[m.logo%len(fits)])
+}
@@ -0,0 +1,115 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// NoteModel edits the user's note about a word, such as a mnemonic
+type NoteModel struct {
+	word     models.Word
+	textarea textarea.Model
+	help     help.Model
+	size     size
+}
+
+// NewNoteModel loads a word and its note for editing
+func NewNoteModel(wordId int64) (*NoteModel, error) {
+	words, err := models.GetWordsByIds([]int64{wordId})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching word %d: %w", wordId, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("word %d isn't in this pack", wordId)
+	}
+	note, err := models.GetNote(1, wordId)
+	if err != nil {
+		return nil, err
+	}
+
+	ta := textarea.New()
+	ta.Placeholder = "A mnemonic, an example, anything that helps you remember"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 1000
+	ta.SetValue(note)
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	ta.FocusedStyle.Text = lipgloss.NewStyle().Foreground(assets.Active.Accent)
+	ta.FocusedStyle.Placeholder = lipgloss.NewStyle().Foreground(assets.Active.Muted)
+	ta.Focus()
+
+	return &NoteModel{word: words[0], textarea: ta, help: newHelp()}, nil
+}
+
+func (m NoteModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+// save stores the note and goes back, or shows an error that can be retried
+func (m NoteModel) save() tea.Cmd {
+	var cmd tea.Cmd
+	cmd = func() tea.Msg {
+		if err := models.SaveNote(1, m.word.Id, m.textarea.Value()); err != nil {
+			return messages.ErrorMsg{Err: fmt.Errorf("error saving note for %s: %w", m.word.Spanish, err), Retry: cmd}
+		}
+		log.Printf("Saved note for word %d\n", m.word.Id)
+		return messages.BackMsg{}
+	}
+	return cmd
+}
+
+func (m NoteModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = newSize(msg)
+		_, horizontal := m.size.padding()
+		m.textarea.SetWidth(max(m.size.cardWidth()-horizontal*2, 10))
+		m.help.Width = m.size.cardWidth()
+		return m, nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Save):
+			return m, m.save()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+func (m NoteModel) View() string {
+	s := lipgloss.NewStyle().Foreground(assets.Active.Accent).Render("Note for ")
+	s += lipgloss.NewStyle().Foreground(assets.Active.Secondary).Render(m.word.DisplaySpanish())
+	s += lipgloss.NewStyle().UnsetBold().Render(" - "+m.word.EnglishPrimary) + "\n\n"
+	s += m.textarea.View() + "\n\n"
+	s += lipgloss.NewStyle().UnsetBold().Render(m.help.View(noteKeys{Keys}))
+
+	_, horizontal := m.size.padding()
+	var style = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(assets.Active.Primary).
+		PaddingTop(1).
+		PaddingBottom(1).
+		PaddingLeft(horizontal).
+		PaddingRight(horizontal).
+		Width(m.size.cardWidth()).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(assets.Active.Primary)
+	return style.Render(s)
+}
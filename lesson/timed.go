@@ -0,0 +1,319 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/messages"
+	"github.com/decarlec/lomo/models"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How often the clocks of a timed run are checked
+const timedTickInterval = 200 * time.Millisecond
+
+// timedRun keeps the clocks and score of a timed run through a lesson
+type timedRun struct {
+	started     time.Time // when the run started, also tells its ticks apart from an earlier run's
+	wordStarted time.Time // when the current word was first shown
+	now         time.Time // time of the last tick, for the clocks shown
+	tries       int       // wrong tries at the current word
+	answers     int       // answers given, skipped or run out of time on
+	right       int       // words answered right in time
+	score       int
+	answerTime  time.Duration // total time taken by the answers typed in
+	typed       int           // answers typed in
+	over        bool
+	timeUp      bool // the run ended before every word was asked
+	saved       bool
+	best        *models.TimedRun // the personal best before this run, once saved
+}
+
+type timedTickMsg struct {
+	started time.Time
+	at      time.Time
+}
+
+type timedSavedMsg struct {
+	started time.Time
+	best    *models.TimedRun
+	err     error
+}
+
+// NewTimedLessonModel races the clock through a lesson's words in a random order.
+// Timed runs start from scratch every time, they aren't resumed or counted towards mastery.
+func NewTimedLessonModel(lessonId int64) (*LessonModel, error) {
+	lesson, err := models.GetLessonByID(lessonId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lesson %d: %w", lessonId, err)
+	}
+	words := lesson.Words
+	rand.Shuffle(len(words), func(i, j int) { words[i], words[j] = words[j], words[i] })
+
+	now := time.Now()
+	return &LessonModel{
+		Lesson:     *lesson,
+		words:      words,
+		textInput:  getLessonInput(),
+		lessonType: "timed",
+		reversed:   directions(len(words)),
+		help:       newHelp(),
+		timed:      timedRun{started: now, wordStarted: now, now: now},
+	}, nil
+}
+
+func (r timedRun) tick() tea.Cmd {
+	started := r.started
+	return tea.Tick(timedTickInterval, func(t time.Time) tea.Msg {
+		return timedTickMsg{started: started, at: t}
+	})
+}
+
+func wordLimit() time.Duration {
+	return time.Duration(Settings.WordSeconds) * time.Second
+}
+
+func runLimit() time.Duration {
+	return time.Duration(Settings.TimedSeconds) * time.Second
+}
+
+// runLeft is the time left for the whole run
+func (r timedRun) runLeft() time.Duration {
+	return max(runLimit()-r.now.Sub(r.started), 0)
+}
+
+// wordLeft is the time left to answer the current word
+func (r timedRun) wordLeft() time.Duration {
+	return max(wordLimit()-r.now.Sub(r.wordStarted), 0)
+}
+
+// points scores a right answer, up to 100 for getting it right plus up to 100 for speed,
+// less 50 for each wrong try first
+func points(latency time.Duration, tries int) int {
+	limit := wordLimit()
+	speed := int(100 * max(limit-latency, 0) / limit)
+	return max(100+speed-50*tries, 0)
+}
+
+// accuracy is the percentage of answers that were right
+func (r timedRun) accuracy() int {
+	if r.answers == 0 {
+		return 0
+	}
+	return r.right * 100 / r.answers
+}
+
+// averageAnswerTime is how long typed answers took on average
+func (r timedRun) averageAnswerTime() time.Duration {
+	if r.typed == 0 {
+		return 0
+	}
+	return r.answerTime / time.Duration(r.typed)
+}
+
+// recordTimedAnswer stores an answer and how long it took
+func (m LessonModel) recordTimedAnswer(word models.Word, correct bool, latency time.Duration) tea.Cmd {
+	lessonId := m.Lesson.Id
+	return func() tea.Msg {
+		if err := models.RecordTimedAnswer(1, lessonId, word.Id, correct, latency); err != nil {
+			log.Printf("Error recording timed answer for word %d: %v\n", word.Id, err)
+			return messages.ToastMsg{Text: "Couldn't record your answer, it won't count towards your daily goal"}
+		}
+		return nil
+	}
+}
+
+// nextTimed moves on to the next word, or finishes the run after the last one
+func (m LessonModel) nextTimed() (LessonModel, tea.Cmd) {
+	m.timed.tries = 0
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = ""
+	if m.current == len(m.words)-1 {
+		return m.finishTimed()
+	}
+	m.current++
+	m.timed.wordStarted = time.Now()
+	m.timed.now = m.timed.wordStarted
+	return m, nil
+}
+
+// finishTimed ends the run and saves it, looking up the personal best it's up against first
+func (m LessonModel) finishTimed() (LessonModel, tea.Cmd) {
+	m.timed.over = true
+	m.textInput.Blur()
+	run := models.TimedRun{
+		UserId:         1,
+		LessonId:       m.Lesson.Id,
+		Score:          m.timed.score,
+		Correct:        m.timed.right,
+		Total:          len(m.words),
+		Accuracy:       m.timed.accuracy(),
+		AverageLatency: m.timed.averageAnswerTime(),
+	}
+	started := m.timed.started
+	log.Printf("Timed run of lesson %d over, scored %d\n", run.LessonId, run.Score)
+	return m, func() tea.Msg {
+		best, err := models.GetPersonalBest(1, run.LessonId)
+		if err == nil {
+			err = models.SaveTimedRun(run)
+		}
+		return timedSavedMsg{started: started, best: best, err: err}
+	}
+}
+
+func (m LessonModel) updateTimed(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	currentWord := &m.words[m.current]
+
+	switch msg := msg.(type) {
+	case timedTickMsg:
+		if msg.started != m.timed.started || m.timed.over {
+			return m, nil
+		}
+		m.timed.now = msg.at
+		if m.timed.runLeft() == 0 {
+			m.timed.timeUp = true
+			m, cmd = m.finishTimed()
+			return m, cmd
+		}
+		if m.timed.wordLeft() == 0 {
+			// Running out of time counts as a wrong answer
+			currentWord.Missed = true
+			m.timed.answers++
+			record := m.recordTimedAnswer(*currentWord, false, wordLimit())
+			m, cmd = m.nextTimed()
+			if m.timed.over {
+				return m, tea.Batch(record, cmd)
+			}
+			return m, tea.Batch(record, m.timed.tick())
+		}
+		return m, m.timed.tick()
+	case timedSavedMsg:
+		if msg.started != m.timed.started {
+			return m, nil
+		}
+		if msg.err != nil {
+			log.Printf("Error saving timed run of lesson %d: %v\n", m.Lesson.Id, msg.err)
+			return m, func() tea.Msg {
+				return messages.ToastMsg{Text: "Couldn't save your timed run, it won't show on the leaderboard"}
+			}
+		}
+		m.timed.saved = true
+		m.timed.best = msg.best
+		return m, nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, Keys.Back):
+			return m, func() tea.Msg {
+				log.Printf("Leaving timed run of lesson %d\n", m.Lesson.Id)
+				return messages.BackMsg{}
+			}
+		case key.Matches(msg, Keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m, nil
+		case m.timed.over:
+			if key.Matches(msg, Keys.Submit) {
+				return m, func() tea.Msg { return messages.BackMsg{} }
+			}
+			return m, nil
+		// No peeking or going back to a word against the clock
+		case key.Matches(msg, Keys.Peek, Keys.Prev, Keys.Note, Keys.Mistakes):
+			return m, nil
+		case key.Matches(msg, Keys.Next):
+			currentWord.Missed = true
+			m.timed.answers++
+			m, cmd = m.nextTimed()
+			return m, cmd
+		case key.Matches(msg, Keys.Submit):
+			input := m.textInput.Value()
+			if strings.TrimSpace(input) == "" {
+				return m, nil
+			}
+			latency := time.Since(m.timed.wordStarted)
+			currentWord.Correct = grade(input, *currentWord, m.asksSpanish(m.current), Settings.Grading)
+			record := m.recordTimedAnswer(*currentWord, currentWord.Correct, latency)
+			m.timed.answers++
+			m.timed.typed++
+			m.timed.answerTime += latency
+			if currentWord.Correct {
+				m.timed.right++
+				m.timed.score += points(latency, m.timed.tries)
+				m, cmd = m.nextTimed()
+				return m, tea.Batch(record, cmd)
+			}
+			currentWord.Missed = true
+			m.timed.tries++
+			m.textInput.Placeholder = "✗ try again!"
+			m.textInput.PlaceholderStyle.Foreground(assets.Active.Wrong)
+			m.textInput.SetValue("")
+			return m, record
+		}
+	}
+
+	if m.timed.over {
+		return m, nil
+	}
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// clock shows a duration as minutes and seconds, rounding up so it only shows 0:00 once time is up
+func clock(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func (m LessonModel) timedView() string {
+	r := m.timed
+	s := fmt.Sprintf("Timed run - Lesson %d - Score %d\n\n", m.Lesson.Id, r.score)
+	if r.over {
+		s += m.timedSummary()
+	} else {
+		clocks := fmt.Sprintf("Word %d/%d - %s left, %s for this word", m.current+1, len(m.words), clock(r.runLeft()), clock(r.wordLeft()))
+		if r.wordLeft() <= 3*time.Second {
+			clocks = lipgloss.NewStyle().Foreground(assets.Active.Wrong).Render(clocks)
+		}
+		s += clocks + "\n\n"
+		s += m.prompt(m.current)
+		s += m.textInput.View() + "\n"
+	}
+
+	s += lipgloss.NewStyle().PaddingTop(1).UnsetBold().Render("\n" + m.help.View(timedKeys{Keys, r.over}))
+	return lessonStyle(s, m.size)
+}
+
+// timedSummary shows the result of a finished run against the personal best
+func (m LessonModel) timedSummary() string {
+	r := m.timed
+	title := "✓ All done!"
+	if r.timeUp {
+		title = "Time's up!"
+	}
+	s := lipgloss.NewStyle().Foreground(assets.Active.Correct).Render(title) + "\n\n"
+	s += fmt.Sprintf("Score: %d\n", r.score)
+	s += fmt.Sprintf("Correct: %d/%d\n", r.right, len(m.words))
+	s += fmt.Sprintf("Accuracy: %d%%\n", r.accuracy())
+	s += fmt.Sprintf("Average answer time: %.1fs\n", r.averageAnswerTime().Seconds())
+
+	if r.saved {
+		best := lipgloss.NewStyle().Foreground(assets.Active.Accent)
+		switch {
+		case r.best == nil:
+			s += "\n" + best.Render("★ First timed run of this lesson, a new personal best!") + "\n"
+		case r.score > r.best.Score:
+			s += "\n" + best.Render(fmt.Sprintf("★ New personal best, up from %d!", r.best.Score)) + "\n"
+		default:
+			s += fmt.Sprintf("\nPersonal best: %d, on %s\n", r.best.Score, r.best.CreatedAt.Local().Format("Jan 2"))
+		}
+	}
+	return s
+}
@@ -1,97 +1,270 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
-	"math/rand"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/decarlec/lomo/assets"
+	"github.com/decarlec/lomo/bootstrap"
+	"github.com/decarlec/lomo/config"
 	"github.com/decarlec/lomo/db"
 	"github.com/decarlec/lomo/lesson"
 	"github.com/decarlec/lomo/messages"
 	"github.com/decarlec/lomo/models"
+	"github.com/decarlec/lomo/pack"
+	"github.com/decarlec/lomo/router"
+	"github.com/decarlec/lomo/speech"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // AppModel is the parent model managing sub-models
 type AppModel struct {
-	currentModel tea.Model
-	mainMenu     *lesson.MainMenuModel
-	lessonMenu   *lesson.LessonMenuModel
-	lesson       *lesson.LessonModel
-	lessonsInProgress []lesson.LessonModel
-	review *lesson.LessonModel
+	router router.Router
+	// Lessons the user left before finishing, resumed when they are opened again
+	lessonsInProgress map[int64]lesson.LessonModel
+	toast             string
+	toastId           int
+	// Terminal size, every screen is told about it
+	width  int
+	height int
+}
+
+// Hides the toast with the given id, unless a newer one replaced it
+type clearToastMsg struct {
+	id int
+}
+
+const toastDuration = 4 * time.Second
+
+// retryMsg sends msg again when the user retries after an error
+func retryMsg(msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return msg
+	}
 }
 
 // AppModel methods
 func (m AppModel) Init() tea.Cmd {
-	return m.currentModel.Init()
+	return m.router.Current().Init()
 }
 
 func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, m.router.Resize(msg)
 	case messages.SwitchToLessonMsg:
-		//Select in progress lesson if possible
-		for _, l := range m.lessonsInProgress {
-			if l.Lesson.Id == msg.LessonId {
-				m.lessonsInProgress = append(m.lessonsInProgress, l)
-				m.lesson = &l
-				m.currentModel = m.lesson
-				return m, nil
+		if msg.Timed {
+			timed, err := lesson.NewTimedLessonModel(msg.LessonId)
+			if err != nil {
+				return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
 			}
+			return m, m.router.Push(*timed)
 		}
-		lessonModel, cmd := lesson.NewLessonModel(msg.LessonId)
-		m.currentModel = lessonModel
-		m.lesson = lessonModel
-		return m, cmd
+		if msg.Dictation {
+			dictation, err := lesson.NewDictationLessonModel(msg.LessonId)
+			if err != nil {
+				return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+			}
+			return m, m.router.Push(*dictation)
+		}
+		//Select in progress lesson if possible
+		if inProgress, ok := m.lessonsInProgress[msg.LessonId]; ok {
+			delete(m.lessonsInProgress, msg.LessonId)
+			return m, m.router.Push(inProgress)
+		}
+		lessonModel, err := lesson.NewLessonModel(msg.LessonId)
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*lessonModel)
 	case messages.SwitchToMenuMsg:
-		m.currentModel = m.mainMenu
-		return m, nil
+		popped, cmd := m.router.PopToRoot()
+		for _, screen := range popped {
+			m.retain(screen)
+		}
+		return m, cmd
 	case messages.SwitchToLessonMenuMsg:
-		if m.lessonMenu == nil {
-			m.lessonMenu, _ = lesson.NewLessonMenuModel()
+		lessonMenu, err := lesson.NewLessonMenuModel()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
 		}
-		m.currentModel = m.lessonMenu
+		return m, m.router.Push(*lessonMenu)
 	case messages.SwitchToReviewMsg:
-		if m.review == nil {
-			m.review, _ = lesson.NewReviewLessonModel(getReviewLesson())
+		// A fresh review every time, so it picks up new words
+		reviewLesson, err := getReviewLesson()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		review, cmd := lesson.NewReviewLessonModel(reviewLesson)
+		return m, tea.Batch(cmd, m.router.Push(*review))
+	case messages.SwitchToMistakesMsg:
+		words, err := models.GetMistakes(1, msg.LessonId, lesson.Settings.MistakesDays)
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
 		}
-		m.currentModel = m.review
+		if len(words) == 0 {
+			return m, func() tea.Msg {
+				return messages.ToastMsg{Text: fmt.Sprintf("No mistakes in the last %d days, nothing to review", lesson.Settings.MistakesDays)}
+			}
+		}
+		review, cmd := lesson.NewReviewLessonModel(&models.Lesson{Words: words})
+		return m, tea.Batch(cmd, m.router.Push(*review))
+	case messages.SwitchToLeechesMsg:
+		leeches, err := lesson.NewLeechesModel()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*leeches)
+	case messages.SwitchToLeaderboardMsg:
+		leaderboard, err := lesson.NewLeaderboardModel()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*leaderboard)
+	case messages.SwitchToConjugationMsg:
+		drill, err := lesson.NewConjugationModel()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*drill)
+	case messages.SwitchToGenderMsg:
+		drill, err := lesson.NewGenderModel()
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*drill)
+	case messages.SwitchToNoteMsg:
+		note, err := lesson.NewNoteModel(msg.WordId)
+		if err != nil {
+			return m, m.router.Push(lesson.NewErrorModel(err, retryMsg(msg)))
+		}
+		return m, m.router.Push(*note)
+	case messages.ErrorMsg:
+		return m, m.router.Push(lesson.NewErrorModel(msg.Err, msg.Retry))
+	case messages.ToastMsg:
+		m.toast = msg.Text
+		m.toastId++
+		id := m.toastId
+		return m, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+			return clearToastMsg{id: id}
+		})
+	case clearToastMsg:
+		if msg.id == m.toastId {
+			m.toast = ""
+		}
+		return m, nil
+	case messages.BackMsg:
+		popped, cmd := m.router.Pop()
+		m.retain(popped)
+		return m, cmd
+	}
+	return m, m.router.Update(msg)
+}
+
+// retain keeps unfinished lessons so they can be resumed
+func (m AppModel) retain(screen tea.Model) {
+	l, ok := screen.(lesson.LessonModel)
+	if !ok || l.IsPractice() || l.Finished() {
+		return
 	}
-	var cmd tea.Cmd
-	m.currentModel, cmd = m.currentModel.Update(msg)
-	return m, cmd
+	m.lessonsInProgress[l.Lesson.Id] = l
 }
 
 func (m AppModel) View() string {
-	return m.currentModel.View()
+	if m.toast != "" {
+		return m.router.View() + "\n" + lesson.ToastView(m.toast, m.width)
+	}
+	return m.router.View()
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	packName := flag.String("pack", "", "language pack to study (see `lomo pack list`)")
+	noColor := flag.Bool("no-color", false, "don't use colours, also set by the NO_COLOR environment variable")
+	flag.Parse()
+
+	// Load settings, a broken config is reported before anything starts
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := lesson.ApplyKeyConfig(cfg.Keys); err != nil {
+		fmt.Printf("Error in config keys: %v\n", err)
+		os.Exit(1)
+	}
+	if err := assets.UseTheme(cfg.Theme, cfg.Themes); err != nil {
+		fmt.Printf("Error in config theme: %v\n", err)
+		os.Exit(1)
+	}
+	voice, err := speech.New(cfg.Speech, cfg.Voice)
+	if err != nil {
+		fmt.Printf("Error in config speech: %v\n", err)
+		os.Exit(1)
+	}
+	lesson.Voice = voice
+	// https://no-color.org
+	if *noColor || os.Getenv("NO_COLOR") != "" {
+		assets.UseNoColor()
+	}
+
+	lesson.Settings = cfg
+
 	// Setup logging
-	f, err := tea.LogToFile("debug.log", "debug")
+	logPath, err := cfg.LogPath()
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+	f, err := tea.LogToFile(logPath, "debug")
 	if err != nil {
 		fmt.Println("fatal:", err)
 		os.Exit(1)
 	}
 	defer f.Close()
 
+	// Find the language pack, installing the built in one on first run
+	if err := pack.EnsureBuiltin(); err != nil {
+		fmt.Printf("Error installing built in pack: %v\n", err)
+		os.Exit(1)
+	}
+	activePack, err := pack.Resolve(*packName)
+	if err != nil {
+		fmt.Printf("Error loading pack: %v\n", err)
+		os.Exit(1)
+	}
+	progress, err := activePack.ProgressPath()
+	if err != nil {
+		fmt.Printf("Error loading pack: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Connect to db
-	if err := db.InitDB(); err != nil {
+	if err := db.InitDB(activePack.DBPath(), progress); err != nil {
 		fmt.Printf("Error initializing database: %v\n", err)
 		os.Exit(1)
 	}
 	defer db.DB.Close()
 
 	// Initialize models
-	mainMenu := initialModel()
 	appModel := AppModel{
-		currentModel: mainMenu,
-		mainMenu:     &mainMenu,
+		router:            router.New(initialModel()),
+		lessonsInProgress: make(map[int64]lesson.LessonModel),
 	}
 	p := tea.NewProgram(appModel)
 	if _, err := p.Run(); err != nil {
@@ -100,24 +273,35 @@ func main() {
 	}
 }
 
-func initialModel() lesson.MainMenuModel {
-	return lesson.MainMenuModel{
-		// Our to-do list is a grocery list
-		Choices: []string{"Lessons", "Review"},
-
-		// A map which indicates which choices are selected. We're using
-		// the  map like a mathematical set. The keys refer to the indexes
-		// of the `choices` slice, above.
-		Selected: make(map[int]struct{}),
-		Logo: lipgloss.NewStyle().Foreground(lipgloss.Color(assets.Purple)).Bold(true).Align(lipgloss.Center).Render(assets.Logos[rand.Intn(len(assets.Logos))]),
+func runCommand(name string, args []string) error {
+	switch name {
+	case "pack":
+		return pack.Command(args)
+	case "config":
+		return config.Command(args)
+	case "bootstrap":
+		return bootstrap.Command(args)
+	default:
+		return fmt.Errorf("unknown command %q, available commands: pack, config, bootstrap", name)
 	}
 }
 
-func getReviewLesson() *models.Lesson {
+func initialModel() lesson.MainMenuModel {
+	menu := lesson.NewMainMenuModel()
+	// Offer to resume a lesson interrupted last time, and show progress towards the daily goal
+	return menu.LoadResume().LoadDailyGoal()
+}
+
+func getReviewLesson() (*models.Lesson, error) {
 	words, err := models.GetAllWords()
 	if err != nil {
-		log.Fatalf("Error fetching all words for review lesson: %v\n", err)
+		return nil, fmt.Errorf("error fetching all words for review lesson: %w", err)
+	}
+	suspended, err := models.GetSuspendedWordIds(1)
+	if err != nil {
+		return nil, err
 	}
+	words = slices.DeleteFunc(words, func(word models.Word) bool { return suspended[word.Id] })
 
-	return &models.Lesson{Words: words}
+	return &models.Lesson{Words: words}, nil
 }
@@ -1,12 +1,52 @@
 package messages
 
+import tea "github.com/charmbracelet/bubbletea"
+
 // Msg types for transitions
 type SwitchToLessonMsg struct {
 	LessonId int64
+	Timed     bool // race the clock through the lesson instead of studying it
+	Dictation bool // type the lesson's words from hearing them
 }
 
 type SwitchToReviewMsg struct {}
 
+// Review the words answered wrong or peeked at recently, in one lesson or all of them when LessonId is 0
+type SwitchToMistakesMsg struct {
+	LessonId int64
+}
+
 type SwitchToLessonMenuMsg struct {}
 
+type SwitchToLeechesMsg struct{}
+
+// Show the best timed run of each lesson
+type SwitchToLeaderboardMsg struct{}
+
+// Drill the conjugations of the pack's verbs
+type SwitchToConjugationMsg struct{}
+
+// Drill the gender of the pack's nouns
+type SwitchToGenderMsg struct{}
+
+// Edit the user's note about a word
+type SwitchToNoteMsg struct {
+	WordId int64
+}
+
 type SwitchToMenuMsg struct{}
+
+
+// Leave the current screen and go back to the previous one
+type BackMsg struct{}
+
+// Show an error screen. If Retry is set the user can choose to run it again.
+type ErrorMsg struct {
+	Err   error
+	Retry tea.Cmd
+}
+
+// Briefly show a message under the current screen, for problems the user can carry on from
+type ToastMsg struct {
+	Text string
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// Answer is one submitted answer to a word
+type Answer struct {
+	Id        int64     `db:"id"`
+	UserId    int64     `db:"user_id"`
+	LessonId  int64     `db:"lesson_id"` // 0 for review lessons
+	WordId    int64     `db:"word_id"`
+	Correct   bool      `db:"correct"`
+	Peeked    bool      `db:"peeked"`     // the answer was shown, these are never correct
+	LatencyMs *int64    `db:"latency_ms"` // time taken to answer, only recorded in timed runs
+	Practice  bool      `db:"practice"`   // given in a timed run or dictation, only counts towards the daily goal
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// RecordAnswer stores a submitted answer
+func RecordAnswer(userId int64, lessonId int64, wordId int64, correct bool) error {
+	_, err := db.DB.Exec("INSERT INTO answers (user_id, lesson_id, word_id, correct) VALUES (?, ?, ?, ?)", userId, lessonId, wordId, correct)
+	return err
+}
+
+// RecordPracticeAnswer stores an answer given in a dictation. Like timed answers it only counts towards the daily goal.
+func RecordPracticeAnswer(userId int64, lessonId int64, wordId int64, correct bool) error {
+	_, err := db.DB.Exec("INSERT INTO answers (user_id, lesson_id, word_id, correct, practice) VALUES (?, ?, ?, ?, 1)", userId, lessonId, wordId, correct)
+	return err
+}
+
+// RecordTimedAnswer stores an answer given in a timed run along with how long it took.
+// It's a practice answer, left out of word progress, leeches and mistakes.
+func RecordTimedAnswer(userId int64, lessonId int64, wordId int64, correct bool, latency time.Duration) error {
+	_, err := db.DB.Exec("INSERT INTO answers (user_id, lesson_id, word_id, correct, latency_ms, practice) VALUES (?, ?, ?, ?, ?, 1)",
+		userId, lessonId, wordId, correct, latency.Milliseconds())
+	return err
+}
+
+// RecordPeek stores that the answer to a word was shown, it counts as a wrong answer
+func RecordPeek(userId int64, lessonId int64, wordId int64) error {
+	_, err := db.DB.Exec("INSERT INTO answers (user_id, lesson_id, word_id, correct, peeked) VALUES (?, ?, ?, 0, 1)", userId, lessonId, wordId)
+	return err
+}
+
+// GetMistakes returns the words answered wrong or peeked at in the last days days, leaving out suspended words.
+// A lessonId other than 0 only looks at answers given in that lesson.
+func GetMistakes(userId int64, lessonId int64, days int) ([]Word, error) {
+	query := `SELECT DISTINCT word_id FROM answers WHERE user_id = ? AND NOT practice AND (NOT correct OR peeked) AND created_at >= datetime('now', ?)
+		AND word_id NOT IN (SELECT word_id FROM word_states WHERE user_id = ? AND suspended)`
+	args := []any{userId, fmt.Sprintf("-%d days", days), userId}
+	if lessonId != 0 {
+		query += " AND lesson_id = ?"
+		args = append(args, lessonId)
+	}
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching mistakes: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning mistake: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return GetWordsByIds(ids)
+}
+
+// CountCorrectToday counts the different words answered correctly since local midnight, practice answers included
+func CountCorrectToday(userId int64) (int, error) {
+	var count int
+	err := db.DB.QueryRow(
+		"SELECT COUNT(DISTINCT word_id) FROM answers WHERE user_id = ? AND correct AND date(created_at, 'localtime') = date('now', 'localtime')",
+		userId).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// GenderProgress is how well a user knows a noun's gender
+type GenderProgress struct {
+	WordId       int64
+	Streak       int // right answers in a row
+	LastAnswered time.Time
+}
+
+func (p GenderProgress) Mastered() bool {
+	return p.Streak >= MasteryStreak
+}
+
+// GetGenderProgress returns the gender progress of every noun the user has been asked, by word id
+func GetGenderProgress(userId int64) (map[int64]GenderProgress, error) {
+	rows, err := db.DB.Query(
+		"SELECT word_id, gender_streak, gender_answered_at FROM word_states WHERE user_id = ? AND gender_answered_at IS NOT NULL",
+		userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching gender progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := map[int64]GenderProgress{}
+	for rows.Next() {
+		var p GenderProgress
+		if err := rows.Scan(&p.WordId, &p.Streak, &p.LastAnswered); err != nil {
+			return nil, fmt.Errorf("error scanning gender progress: %w", err)
+		}
+		progress[p.WordId] = p
+	}
+	return progress, rows.Err()
+}
+
+// RecordGender stores an answer to a noun's gender, a wrong one starts its streak over
+func RecordGender(userId int64, wordId int64, correct bool) error {
+	streak := 0
+	if correct {
+		streak = 1
+	}
+	_, err := db.DB.Exec(
+		`INSERT INTO word_states (user_id, word_id, gender_streak, gender_answered_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, word_id) DO UPDATE SET
+			gender_streak = CASE WHEN ? THEN gender_streak + 1 ELSE 0 END,
+			gender_answered_at = excluded.gender_answered_at`,
+		userId, wordId, streak, correct)
+	return err
+}
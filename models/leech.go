@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// Leech is a word got wrong so often that reviewing it wastes time
+type Leech struct {
+	Word      Word
+	Lapses    int  // wrong answers and peeks since the word was last reset
+	Suspended bool // left out of reviews
+	Note      string
+}
+
+// GetLeeches returns the words with at least lapses wrong answers since they were last reset,
+// and any suspended words, most lapses first
+func GetLeeches(userId int64, lapses int) ([]Leech, error) {
+	rows, err := db.DB.Query(`SELECT a.word_id, COUNT(*) AS lapses, COALESCE(s.suspended, 0) FROM answers a
+		LEFT JOIN word_states s ON s.user_id = a.user_id AND s.word_id = a.word_id
+		WHERE a.user_id = ? AND NOT a.correct AND NOT a.practice AND (s.reset_at IS NULL OR a.created_at > s.reset_at)
+		GROUP BY a.word_id
+		HAVING lapses >= ? OR COALESCE(s.suspended, 0)
+		ORDER BY lapses DESC, a.word_id`, userId, lapses)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching leeches: %w", err)
+	}
+	defer rows.Close()
+
+	leeches := []Leech{}
+	ids := []int64{}
+	for rows.Next() {
+		var leech Leech
+		if err := rows.Scan(&leech.Word.Id, &leech.Lapses, &leech.Suspended); err != nil {
+			return nil, fmt.Errorf("error scanning leech: %w", err)
+		}
+		leeches = append(leeches, leech)
+		ids = append(ids, leech.Word.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	words, err := GetWordsByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]Word, len(words))
+	for _, word := range words {
+		byId[word.Id] = word
+	}
+	notes, err := GetNotes(userId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Words no longer in the pack are left out
+	found := leeches[:0]
+	for _, leech := range leeches {
+		word, ok := byId[leech.Word.Id]
+		if !ok {
+			continue
+		}
+		leech.Word = word
+		leech.Note = notes[word.Id]
+		found = append(found, leech)
+	}
+	return found, nil
+}
+
+// SetSuspended suspends a word, leaving it out of reviews, or brings it back
+func SetSuspended(userId int64, wordId int64, suspended bool) error {
+	_, err := db.DB.Exec(
+		`INSERT INTO word_states (user_id, word_id, suspended) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, word_id) DO UPDATE SET suspended = excluded.suspended`,
+		userId, wordId, suspended)
+	return err
+}
+
+// ResetWord starts a word over, answers given before now no longer count towards its lapses or mastery
+// and its gender has to be learned again. Suspended words are brought back.
+func ResetWord(userId int64, wordId int64) error {
+	_, err := db.DB.Exec(
+		`INSERT INTO word_states (user_id, word_id, suspended, reset_at) VALUES (?, ?, 0, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, word_id) DO UPDATE SET suspended = 0, reset_at = excluded.reset_at, gender_streak = 0`,
+		userId, wordId)
+	return err
+}
+
+// GetSuspendedWordIds returns the ids of the words the user has suspended
+func GetSuspendedWordIds(userId int64) (map[int64]bool, error) {
+	rows, err := db.DB.Query("SELECT word_id FROM word_states WHERE user_id = ? AND suspended", userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching suspended words: %w", err)
+	}
+	defer rows.Close()
+
+	suspended := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning suspended word: %w", err)
+		}
+		suspended[id] = true
+	}
+	return suspended, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,12 +14,13 @@ import (
 type Word struct {
 	Id              int64    `db:"id"`
 	Spanish         string   `db:"spanish"`
-	EnglishTranslations       string   `db:"english_translations"` // Stored as comma-separated string
 	EnglishPrimary  string   `db:"english_primary"`
-	WordType        string   `db:"word_type"`
-	English_Translations []string `db:"-"` // Ignore in database; load manually
+	Senses          []Sense  `db:"-"` // Loaded from the senses table
+	English_Translations []string `db:"-"` // Ignore in database; built from the sense glosses
 	Correct         bool     `db:"-"` // Ignore in database
 	Peek            bool     `db:"-"` // Ignore in database
+	Missed          bool     `db:"-"` // Answered wrong at least once this lesson
+	Peeked          bool     `db:"-"` // Answer shown at least once this lesson, only the first peek is recorded
 }
 
 type User struct {
@@ -49,25 +51,28 @@ type History struct {
 
 func GetWordByID(db *sql.DB, id int64) (Word, error) {
 	var word Word
-	query := `SELECT id, spanish, english_translations, english_primary, word_type FROM words WHERE id = ?`
+	query := `SELECT id, spanish, english_primary FROM words WHERE id = ?`
 	
 	err := db.QueryRow(query, id).Scan(
 		&word.Id,
 		&word.Spanish,
-		&word.EnglishTranslations,
 		&word.EnglishPrimary,
-		&word.WordType,
 	)
 	if err != nil {
 		return Word{}, err
 	}
+	word.Senses, err = getSenses(db, []int64{word.Id})
+	if err != nil {
+		return Word{}, err
+	}
+	word.English_Translations = glosses(word.Senses)
 	
 	return word, nil
 }
 
 func GetAllWords() ([]Word, error) {
 	db := db.DB;
-	query := "SELECT id, spanish, english_translations, english_primary, word_type FROM words"
+	query := "SELECT id, spanish, english_primary FROM words"
 
 	words := []Word{}
 
@@ -79,53 +84,140 @@ func GetAllWords() ([]Word, error) {
 
 	for rows.Next() {
 		var word Word
-		rows.Scan(&word.Id, &word.Spanish, &word.EnglishTranslations, &word.EnglishPrimary, &word.WordType)
-		//Need to process the English translations into a slice
-		word.English_Translations = strings.Split(word.EnglishTranslations, ",")
+		rows.Scan(&word.Id, &word.Spanish, &word.EnglishPrimary)
 		words = append(words, word)
 	}
 
+	if err := loadSenses(words); err != nil {
+		return nil, err
+	}
 	return words, nil
 }
 
-func GetLessonByID(id int64) (*Lesson, error) {
-	var lesson Lesson
-
-	// Get lesson
-	err := db.DB.QueryRow("SELECT id, word_ids FROM lessons WHERE id = ?", id).Scan(
-		&lesson.Id, &lesson.WordIDs,
+// GetWordsByIds returns the words with the given ids, ids of words no longer in the pack are skipped
+func GetWordsByIds(ids []int64) ([]Word, error) {
+	words := []Word{}
+	if len(ids) == 0 {
+		return words, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := fmt.Sprintf(
+		"SELECT id, spanish, english_primary FROM words WHERE id IN (%s)",
+		strings.TrimRight(strings.Repeat("?,", len(ids)), ","),
 	)
+
+	rows, err := db.DB.Query(query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("error fetching words: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word Word
+		if err := rows.Scan(&word.Id, &word.Spanish, &word.EnglishPrimary); err != nil {
+			return nil, fmt.Errorf("error scanning word: %w", err)
+		}
+		words = append(words, word)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	// Parse word IDs and query in one go
-	wordIDStrs := strings.Split(lesson.WordIDs, ",")
-	if len(wordIDStrs) == 0 {
-		return &lesson, nil
+	if err := loadSenses(words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// Fills in the senses of each word, and the translations built from them
+func loadSenses(words []Word) error {
+	ids := make([]int64, len(words))
+	for i, word := range words {
+		ids[i] = word.Id
+	}
+	senses, err := getSenses(db.DB, ids)
+	if err != nil {
+		return err
 	}
 
-	// Build query with placeholders
-	placeholders := strings.Join(wordIDStrs, ",")
+	index := make(map[int64]int, len(words))
+	for i, word := range words {
+		index[word.Id] = i
+	}
+	for _, sense := range senses {
+		if i, ok := index[sense.WordId]; ok {
+			words[i].Senses = append(words[i].Senses, sense)
+		}
+	}
+	for i := range words {
+		words[i].English_Translations = glosses(words[i].Senses)
+	}
+	return nil
+}
+
+// One translation per sense, in dictionary order
+func glosses(senses []Sense) []string {
+	translations := make([]string, len(senses))
+	for i, sense := range senses {
+		translations[i] = sense.Gloss
+	}
+	return translations
+}
+
+// Returns the senses for the given words in dictionary order
+func getSenses(db *sql.DB, wordIds []int64) ([]Sense, error) {
+	if len(wordIds) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(wordIds))
+	for i, id := range wordIds {
+		args[i] = id
+	}
 	query := fmt.Sprintf(
-		"SELECT id, spanish, english_translations, english_primary, word_type FROM words WHERE id IN (%s)",
-		placeholders,
+		"SELECT id, word_id, position, gloss, part_of_speech, gender, plural, label FROM senses WHERE word_id IN (%s) ORDER BY word_id, position",
+		strings.TrimRight(strings.Repeat("?,", len(wordIds)), ","),
 	)
 
-	rows, err := db.DB.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error fetching senses: %w", err)
 	}
 	defer rows.Close()
 
+	senses := []Sense{}
 	for rows.Next() {
-		var word Word
-		rows.Scan(&word.Id, &word.Spanish, &word.EnglishTranslations, &word.EnglishPrimary, &word.WordType)
-		//Need to process the English translations into a slice
-		word.English_Translations = strings.Split(word.EnglishTranslations, ",")
-		lesson.Words = append(lesson.Words, word)
+		var sense Sense
+		err := rows.Scan(&sense.Id, &sense.WordId, &sense.Position, &sense.Gloss, &sense.PartOfSpeech, &sense.Gender, &sense.Plural, &sense.Label)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sense: %w", err)
+		}
+		senses = append(senses, sense)
 	}
+	return senses, rows.Err()
+}
 
+func GetLessonByID(id int64) (*Lesson, error) {
+	var lesson Lesson
+
+	// Get lesson
+	err := db.DB.QueryRow("SELECT id, word_ids FROM lessons WHERE id = ?", id).Scan(
+		&lesson.Id, &lesson.WordIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Packs can come from anywhere, the ids are parsed rather than put in the query as they are
+	words, err := GetWordsByIds(lesson.Ids())
+	if err != nil {
+		return nil, err
+	}
+	if len(words) > 0 {
+		lesson.Words = words
+	}
 	return &lesson, nil
 }
 
@@ -173,13 +265,37 @@ func GetHistoryForLesson(lessonId int64) ([]History, error) {
 
 	for rows.Next() {
 		var history History
-		rows.Scan(&history.Id, &history.LessonId, &history.CorrectIds, &history.CreatedAt, &history.UserId)
-		//Need to process the English translations into a slice
+		var correctIds sql.NullString
+		if err := rows.Scan(&history.Id, &history.LessonId, &history.UserId, &correctIds, &history.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning history: %w", err)
+		}
+		history.CorrectIds = correctIds.String
 
 		histories = append(histories, history)
 	}
 
-	return histories, nil
+	return histories, rows.Err()
+}
+
+// CorrectWordIds parses CorrectIds, which has a trailing comma
+func (h History) CorrectWordIds() []int64 {
+	return parseIds(h.CorrectIds)
+}
+
+// Ids of the lesson's words, in lesson order
+func (l Lesson) Ids() []int64 {
+	return parseIds(l.WordIDs)
+}
+
+func parseIds(csv string) []int64 {
+	ids := []int64{}
+	for field := range strings.SplitSeq(csv, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 func WriteHistory(lesson Lesson, userId int, words []Word) error {
@@ -195,6 +311,35 @@ func WriteHistory(lesson Lesson, userId int, words []Word) error {
 }
 
 
+// InsertWord inserts a word and its senses, keeping word.Id if it is set.
+// Each sense is stored as its own row, English_Translations is not stored.
+func InsertWord(tx *sql.Tx, word Word) error {
+	var id any
+	if word.Id != 0 {
+		id = word.Id
+	}
+	res, err := tx.Exec(
+		"INSERT INTO words (id, spanish, english_primary) VALUES (?, ?, ?)",
+		id, word.Spanish, word.EnglishPrimary)
+	if err != nil {
+		return err
+	}
+	wordId, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for position, sense := range word.Senses {
+		_, err := tx.Exec(
+			"INSERT INTO senses (word_id, position, gloss, part_of_speech, gender, plural, label) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			wordId, position, sense.Gloss, sense.PartOfSpeech, sense.Gender, sense.Plural, sense.Label)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (u User) String() string {
 	return fmt.Sprintf("User<%d %s %v>", u.Id, u.Name, u.Emails)
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// GetNote returns the user's note about a word, empty if there isn't one
+func GetNote(userId int64, wordId int64) (string, error) {
+	var text string
+	err := db.DB.QueryRow("SELECT text FROM notes WHERE user_id = ? AND word_id = ?", userId, wordId).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching note: %w", err)
+	}
+	return text, nil
+}
+
+// GetNotes returns the user's notes about the given words, by word id
+func GetNotes(userId int64, wordIds []int64) (map[int64]string, error) {
+	notes := map[int64]string{}
+	if len(wordIds) == 0 {
+		return notes, nil
+	}
+	args := []any{userId}
+	for _, id := range wordIds {
+		args = append(args, id)
+	}
+	query := fmt.Sprintf(
+		"SELECT word_id, text FROM notes WHERE user_id = ? AND word_id IN (%s)",
+		strings.TrimRight(strings.Repeat("?,", len(wordIds)), ","),
+	)
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching notes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wordId int64
+		var text string
+		if err := rows.Scan(&wordId, &text); err != nil {
+			return nil, fmt.Errorf("error scanning note: %w", err)
+		}
+		notes[wordId] = text
+	}
+	return notes, rows.Err()
+}
+
+// SaveNote stores the user's note about a word, an empty note deletes it
+func SaveNote(userId int64, wordId int64, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		_, err := db.DB.Exec("DELETE FROM notes WHERE user_id = ? AND word_id = ?", userId, wordId)
+		return err
+	}
+	_, err := db.DB.Exec(
+		`INSERT INTO notes (user_id, word_id, text, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, word_id) DO UPDATE SET text = excluded.text, updated_at = excluded.updated_at`,
+		userId, wordId, text)
+	return err
+}
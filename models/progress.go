@@ -0,0 +1,181 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// Correct answers in a row for a word to count as mastered
+const MasteryStreak = 3
+
+// The longest gap between reviews of a word, however long its streak
+const maxReviewInterval = 60 * 24 * time.Hour
+
+// WordProgress summarises the answers given to a word
+type WordProgress struct {
+	WordId       int64
+	Answers      int
+	Streak       int // correct answers in a row, up to the latest one
+	LastAnswered time.Time
+}
+
+func (p WordProgress) Mastered() bool {
+	return p.Streak >= MasteryStreak
+}
+
+// Due reports whether the word should be reviewed. Words answered wrong last time are due straight
+// away, otherwise the wait after the latest answer starts at a day and doubles with each correct answer in a row.
+func (p WordProgress) Due(now time.Time) bool {
+	if p.Answers == 0 {
+		return false
+	}
+	if p.Streak == 0 {
+		return true
+	}
+	interval := maxReviewInterval
+	if p.Streak <= 6 {
+		interval = 24 * time.Hour << (p.Streak - 1)
+	}
+	return !now.Before(p.LastAnswered.Add(interval))
+}
+
+// GetWordProgress summarises every answer a user has given, by word id. Words never answered are left out.
+func GetWordProgress(userId int64) (map[int64]WordProgress, error) {
+	// Answers from before a word was reset and practice answers don't count
+	rows, err := db.DB.Query(`SELECT a.word_id, a.correct, a.created_at FROM answers a
+		LEFT JOIN word_states s ON s.user_id = a.user_id AND s.word_id = a.word_id
+		WHERE a.user_id = ? AND NOT a.practice AND (s.reset_at IS NULL OR a.created_at > s.reset_at)
+		ORDER BY a.created_at, a.id`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching answers: %w", err)
+	}
+	defer rows.Close()
+
+	progress := map[int64]WordProgress{}
+	for rows.Next() {
+		var wordId int64
+		var correct bool
+		var createdAt time.Time
+		if err := rows.Scan(&wordId, &correct, &createdAt); err != nil {
+			return nil, fmt.Errorf("error scanning answer: %w", err)
+		}
+		p := progress[wordId]
+		p.WordId = wordId
+		p.Answers++
+		p.Streak++
+		if !correct {
+			p.Streak = 0
+		}
+		p.LastAnswered = createdAt
+		progress[wordId] = p
+	}
+	return progress, rows.Err()
+}
+
+// LessonProgress is how far a user has got with a lesson
+type LessonProgress struct {
+	Lesson      Lesson
+	Total       int       // words in the lesson
+	Correct     int       // words right in the latest attempt
+	Best        int       // most words right in any attempt
+	LastStudied time.Time // zero if never studied
+	Mastered    int       // words with a streak of MasteryStreak correct answers
+	Due         int       // words due for review
+	Locked      bool      // waiting on the lesson before it, see Unlock
+}
+
+// Mastery is the percentage of the lesson's words mastered
+func (p LessonProgress) Mastery() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Mastered * 100 / p.Total
+}
+
+func (p LessonProgress) Started() bool {
+	return !p.LastStudied.IsZero()
+}
+
+// Complete reports whether every word of the lesson is mastered
+func (p LessonProgress) Complete() bool {
+	return p.Total > 0 && p.Mastered == p.Total
+}
+
+// Unlock locks the lessons not yet reached. Lessons open in order of their ids, each once the one
+// before it has threshold percent of its words mastered. Lessons already started are never locked.
+func Unlock(progress []LessonProgress, threshold int) {
+	order := make([]int, len(progress))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(progress[a].Lesson.Id, progress[b].Lesson.Id) })
+
+	open := true
+	for _, i := range order {
+		p := &progress[i]
+		p.Locked = !open && !p.Started()
+		open = !p.Locked && p.Mastery() >= threshold
+	}
+}
+
+// GetLessonProgress works out the progress of each lesson from its history and the answers in words
+func GetLessonProgress(lessons []Lesson, words map[int64]WordProgress, now time.Time) ([]LessonProgress, error) {
+	progress := make([]LessonProgress, len(lessons))
+	for i, lesson := range lessons {
+		histories, err := GetHistoryForLesson(lesson.Id)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching history for lesson %d: %w", lesson.Id, err)
+		}
+
+		ids := lesson.Ids()
+		inLesson := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			inLesson[id] = true
+		}
+		p := LessonProgress{Lesson: lesson, Total: len(ids)}
+
+		var newest History
+		for _, history := range histories {
+			correct := 0
+			for _, id := range history.CorrectWordIds() {
+				if inLesson[id] {
+					correct++
+				}
+			}
+			p.Best = max(p.Best, correct)
+			if !history.CreatedAt.Before(newest.CreatedAt) {
+				newest = history
+				p.Correct = correct
+			}
+		}
+		p.LastStudied = newest.CreatedAt
+
+		for _, id := range ids {
+			word, ok := words[id]
+			if !ok {
+				continue
+			}
+			if word.Mastered() {
+				p.Mastered++
+			}
+			if word.Due(now) {
+				p.Due++
+			}
+			if word.LastAnswered.After(p.LastStudied) {
+				p.LastStudied = word.LastAnswered
+			}
+		}
+		progress[i] = p
+	}
+	return progress, nil
+}
+
+// DeleteMissingWordStates removes the states of words the pack no longer has, such as after an upgrade
+func DeleteMissingWordStates() error {
+	_, err := db.DB.Exec("DELETE FROM main.word_states WHERE word_id NOT IN (SELECT id FROM pack.words)")
+	return err
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/pronounce"
+)
+
+type PartOfSpeech string
+
+const (
+	Noun         PartOfSpeech = "noun"
+	ProperNoun   PartOfSpeech = "proper noun"
+	Verb         PartOfSpeech = "verb"
+	Adjective    PartOfSpeech = "adjective"
+	Adverb       PartOfSpeech = "adverb"
+	Pronoun      PartOfSpeech = "pronoun"
+	Preposition  PartOfSpeech = "preposition"
+	Conjunction  PartOfSpeech = "conjunction"
+	Article      PartOfSpeech = "article"
+	Determiner   PartOfSpeech = "determiner"
+	Numeral      PartOfSpeech = "numeral"
+	Interjection PartOfSpeech = "interjection"
+	Phrase       PartOfSpeech = "phrase"
+	Affix        PartOfSpeech = "affix"
+	Abbreviation PartOfSpeech = "abbreviation"
+	Contraction  PartOfSpeech = "contraction"
+	Letter       PartOfSpeech = "letter"
+	Symbol       PartOfSpeech = "symbol"
+	Other        PartOfSpeech = "other"
+)
+
+type Gender string
+
+const (
+	NoGender  Gender = ""
+	Masculine Gender = "m"
+	Feminine  Gender = "f"
+	// Same form for both, e.g. el/la estudiante
+	CommonGender Gender = "mf"
+)
+
+// Sense is one meaning of a word, a single dictionary entry
+type Sense struct {
+	Id           int64        `db:"id"`
+	WordId       int64        `db:"word_id"`
+	Position     int          `db:"position"` // order of the entry in the dictionary
+	Gloss        string       `db:"gloss"`
+	PartOfSpeech PartOfSpeech `db:"part_of_speech"`
+	Gender       Gender       `db:"gender"`
+	Plural       bool         `db:"plural"`
+	Label        string       `db:"label"` // usage label, e.g. colloquial or Mexico
+}
+
+// Dictionary type tags without their gender/number suffix
+var partsOfSpeech = map[string]PartOfSpeech{
+	"":             Noun, // bare {m}, {f}, {mf}, {mp}...
+	"n":            Noun,
+	"prop":         ProperNoun,
+	"v":            Verb,
+	"vt":           Verb,
+	"vi":           Verb,
+	"vr":           Verb,
+	"vp":           Verb,
+	"vtr":          Verb,
+	"vir":          Verb,
+	"vit":          Verb,
+	"vitr":         Verb,
+	"vrr":          Verb,
+	"adj":          Adjective,
+	"adv":          Adverb,
+	"pron":         Pronoun,
+	"prep":         Preposition,
+	"conj":         Conjunction,
+	"art":          Article,
+	"determiner":   Determiner,
+	"num":          Numeral,
+	"cardinal num": Numeral,
+	"interj":       Interjection,
+	"phrase":       Phrase,
+	"proverb":      Phrase,
+	"idiom":        Phrase,
+	"prefix":       Affix,
+	"suffix":       Affix,
+	"affix":        Affix,
+	"abbr":         Abbreviation,
+	"initialism":   Abbreviation,
+	"acronym":      Abbreviation,
+	"contraction":  Contraction,
+	"letter":       Letter,
+	"symbol":       Symbol,
+}
+
+// Matches types like "{fp} [colloquial]"
+var wordTypePattern = regexp.MustCompile(`^\{([^}]*)\}\s*(?:\[(.*)\])?`)
+
+// Gender and number suffixes, longest first so "mfp" isn't read as "p"
+var genderSuffixes = []struct {
+	suffix string
+	gender Gender
+	plural bool
+}{
+	{"mfp", CommonGender, true},
+	{"mf", CommonGender, false},
+	{"mp", Masculine, true},
+	{"fp", Feminine, true},
+	{"m", Masculine, false},
+	{"f", Feminine, false},
+	{"p", NoGender, true},
+}
+
+// ParseWordType parses a dictionary type such as "{f}", "{vt}" or "{m} [Mexico]" into a sense
+func ParseWordType(raw string) Sense {
+	sense := Sense{PartOfSpeech: Other}
+	match := wordTypePattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if match == nil {
+		return sense
+	}
+	tag := strings.TrimSpace(match[1])
+	sense.Label = strings.TrimSpace(match[2])
+
+	if pos, ok := partsOfSpeech[tag]; ok && tag != "" {
+		sense.PartOfSpeech = pos
+		return sense
+	}
+	// Strip a trailing gender/number, e.g. "propf", "adjmf", "mp"
+	for _, s := range genderSuffixes {
+		base, found := strings.CutSuffix(tag, s.suffix)
+		if !found {
+			continue
+		}
+		if pos, ok := partsOfSpeech[base]; ok {
+			sense.PartOfSpeech = pos
+			sense.Gender = s.gender
+			sense.Plural = s.plural
+			return sense
+		}
+	}
+	return sense
+}
+
+// Abbreviation used when displaying a sense, e.g. "f" or "adj"
+func (s Sense) Abbreviation() string {
+	if s.Gender != NoGender && (s.PartOfSpeech == Noun || s.PartOfSpeech == ProperNoun) {
+		if s.Plural {
+			return string(s.Gender) + " pl"
+		}
+		return string(s.Gender)
+	}
+	switch s.PartOfSpeech {
+	case Noun:
+		return "n"
+	case Verb:
+		return "v"
+	case Adjective:
+		return "adj"
+	case Adverb:
+		return "adv"
+	case Pronoun:
+		return "pron"
+	case Preposition:
+		return "prep"
+	case Conjunction:
+		return "conj"
+	case Interjection:
+		return "interj"
+	case Abbreviation:
+		return "abbr"
+	}
+	return string(s.PartOfSpeech)
+}
+
+func (s Sense) String() string {
+	text := fmt.Sprintf("%s (%s)", s.Gloss, s.Abbreviation())
+	if s.Label != "" {
+		text += fmt.Sprintf(" [%s]", s.Label)
+	}
+	return text
+}
+
+// Removes bracketed descriptive text like "(informal)" from glosses
+var bracketPattern = regexp.MustCompile(`[\(\{\[].*?[\}\)\]]`)
+
+// GlossPhrases splits a gloss into its lower case comma or semicolon separated phrases, without bracketed text
+func GlossPhrases(gloss string) []string {
+	phrases := []string{}
+	gloss = strings.ToLower(bracketPattern.ReplaceAllString(gloss, ""))
+	for part := range strings.FieldsFuncSeq(gloss, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			phrases = append(phrases, part)
+		}
+	}
+	return phrases
+}
+
+// GlossMatches reports whether english appears as a whole word or phrase in gloss, ignoring case and bracketed text
+func GlossMatches(gloss string, english string) bool {
+	english = strings.ToLower(strings.TrimSpace(english))
+	if english == "" {
+		return false
+	}
+	phrases := GlossPhrases(gloss)
+	if slices.Contains(phrases, english) {
+		return true
+	}
+	return slices.ContainsFunc(phrases, func(phrase string) bool {
+		return slices.ContainsFunc(strings.Fields(phrase), func(field string) bool {
+			return strings.Trim(field, ".") == english
+		})
+	})
+}
+
+// PrimarySense is the sense matching the word's primary translation.
+// matched is false when no gloss matches and the first sense was used instead.
+func (w Word) PrimarySense() (sense Sense, matched bool) {
+	if len(w.Senses) == 0 {
+		return Sense{}, false
+	}
+	for _, sense := range w.Senses {
+		if GlossMatches(sense.Gloss, w.EnglishPrimary) {
+			return sense, true
+		}
+	}
+	return w.Senses[0], false
+}
+
+// Gender of the word when its primary sense is a noun
+func (w Word) Gender() (Gender, bool) {
+	sense, matched := w.PrimarySense()
+	if !matched {
+		// Without a match only trust the gender when every sense agrees
+		for _, other := range w.Senses {
+			if other.PartOfSpeech != sense.PartOfSpeech || other.Gender != sense.Gender {
+				return NoGender, false
+			}
+		}
+	}
+	if sense.PartOfSpeech != Noun || sense.Gender == NoGender {
+		return NoGender, false
+	}
+	return sense.Gender, true
+}
+
+// DisplaySpanish shows nouns with their article and gender, e.g. "la casa (f)"
+func (w Word) DisplaySpanish() string {
+	gender, ok := w.Gender()
+	if !ok {
+		return w.Spanish
+	}
+	definite, _, _ := w.Articles()
+	return fmt.Sprintf("%s %s (%s)", definite, w.Spanish, gender)
+}
+
+// Articles are the definite and indefinite articles a noun takes, e.g. "la" and "una".
+// Feminine nouns starting with a stressed a take el and un, "el agua".
+func (w Word) Articles() (definite string, indefinite string, ok bool) {
+	gender, ok := w.Gender()
+	if !ok {
+		return "", "", false
+	}
+	sense, _ := w.PrimarySense()
+	if sense.Plural {
+		definites := map[Gender]string{Masculine: "los", Feminine: "las", CommonGender: "los/las"}
+		indefinites := map[Gender]string{Masculine: "unos", Feminine: "unas", CommonGender: "unos/unas"}
+		return definites[gender], indefinites[gender], true
+	}
+	if gender == Feminine && startsStressedA(w.Spanish) {
+		return "el", "un", true
+	}
+	definites := map[Gender]string{Masculine: "el", Feminine: "la", CommonGender: "el/la"}
+	indefinites := map[Gender]string{Masculine: "un", Feminine: "una", CommonGender: "un/una"}
+	return definites[gender], indefinites[gender], true
+}
+
+// startsStressedA reports whether a word starts with a stressed a or ha sound, as in agua or hambre
+func startsStressedA(word string) bool {
+	if strings.Contains(word, " ") {
+		return false
+	}
+	first := strings.TrimPrefix(pronounce.Syllables(word)[0], "h")
+	return pronounce.Stressed(word) == 0 && (strings.HasPrefix(first, "a") || strings.HasPrefix(first, "á"))
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// Sentence is an example of a word in use, with its translation
+type Sentence struct {
+	Id      int64  `db:"id"`
+	WordId  int64  `db:"word_id"`
+	Spanish string `db:"spanish"`
+	English string `db:"english"`
+}
+
+// Token is a word of a sentence, lower cased, and where it is in the sentence
+type Token struct {
+	Text  string
+	Start int // byte offsets
+	End   int
+}
+
+// Tokenize splits text into its words, anything that isn't a letter or digit separates them
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			tokens = append(tokens, Token{Text: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+// Find returns where word appears in the sentence as whole words, ignoring case
+func (s Sentence) Find(word string) (start int, end int, ok bool) {
+	want := Tokenize(word)
+	if len(want) == 0 {
+		return 0, 0, false
+	}
+	tokens := Tokenize(s.Spanish)
+	for i := 0; i+len(want) <= len(tokens); i++ {
+		matched := true
+		for j := range want {
+			if tokens[i+j].Text != want[j].Text {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return tokens[i].Start, tokens[i+len(want)-1].End, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Cloze blanks word out of the sentence, one underscore per letter
+func (s Sentence) Cloze(word string) (string, bool) {
+	start, end, ok := s.Find(word)
+	if !ok {
+		return "", false
+	}
+	blank := strings.Repeat("_", max(utf8.RuneCountInString(s.Spanish[start:end]), 3))
+	return s.Spanish[:start] + blank + s.Spanish[end:], true
+}
+
+// GetSentences returns the example sentences of the given words by word id, shortest first
+func GetSentences(wordIds []int64) (map[int64][]Sentence, error) {
+	sentences := map[int64][]Sentence{}
+	if len(wordIds) == 0 {
+		return sentences, nil
+	}
+	args := make([]any, len(wordIds))
+	for i, id := range wordIds {
+		args[i] = id
+	}
+	query := fmt.Sprintf(
+		"SELECT id, word_id, spanish, english FROM sentences WHERE word_id IN (%s) ORDER BY word_id, length(spanish), id",
+		strings.TrimRight(strings.Repeat("?,", len(wordIds)), ","),
+	)
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching sentences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sentence Sentence
+		if err := rows.Scan(&sentence.Id, &sentence.WordId, &sentence.Spanish, &sentence.English); err != nil {
+			return nil, fmt.Errorf("error scanning sentence: %w", err)
+		}
+		sentences[sentence.WordId] = append(sentences[sentence.WordId], sentence)
+	}
+	return sentences, rows.Err()
+}
+
+// ReplaceSentences swaps every example sentence for the given ones
+func ReplaceSentences(sentences []Sentence) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM sentences"); err != nil {
+		return fmt.Errorf("error clearing sentences: %w", err)
+	}
+	stmt, err := tx.Prepare("INSERT INTO sentences (word_id, spanish, english) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, sentence := range sentences {
+		if _, err := stmt.Exec(sentence.WordId, sentence.Spanish, sentence.English); err != nil {
+			return fmt.Errorf("error saving sentence %q: %w", sentence.Spanish, err)
+		}
+	}
+	return tx.Commit()
+}
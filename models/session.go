@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// Session is the saved state of an unfinished lesson, so it can be resumed after lomo is restarted
+type Session struct {
+	Id        int64         `db:"id"`
+	UserId    int64         `db:"user_id"`
+	LessonId  int64         `db:"lesson_id"`
+	Current   int           `db:"current"` // index into Words of the word being shown
+	Input     string        `db:"input"`   // unsubmitted answer
+	Words     []SessionWord `db:"words"`   // Stored as JSON, in the order they are shown
+	UpdatedAt time.Time     `db:"updated_at"`
+}
+
+type SessionWord struct {
+	WordId  int64 `json:"word_id"`
+	Correct bool  `json:"correct"`
+	Peek    bool  `json:"peek"`
+	Peeked  bool  `json:"peeked"`
+}
+
+// NewSession captures the state of a lesson
+func NewSession(userId int64, lessonId int64, words []Word, current int, input string) Session {
+	session := Session{UserId: userId, LessonId: lessonId, Current: current, Input: input}
+	for _, word := range words {
+		session.Words = append(session.Words, SessionWord{WordId: word.Id, Correct: word.Correct, Peek: word.Peek, Peeked: word.Peeked})
+	}
+	return session
+}
+
+// Restore applies the session to a lesson's words, returning them in session order.
+// Words no longer in the session (e.g. the pack was upgraded) are appended.
+func (s Session) Restore(words []Word) []Word {
+	byId := make(map[int64]Word, len(words))
+	for _, word := range words {
+		byId[word.Id] = word
+	}
+
+	restored := make([]Word, 0, len(words))
+	for _, saved := range s.Words {
+		word, ok := byId[saved.WordId]
+		if !ok {
+			continue
+		}
+		delete(byId, saved.WordId)
+		word.Correct = saved.Correct
+		word.Peek = saved.Peek
+		word.Peeked = saved.Peeked || saved.Peek
+		restored = append(restored, word)
+	}
+	for _, word := range words {
+		if _, ok := byId[word.Id]; ok {
+			restored = append(restored, word)
+		}
+	}
+	return restored
+}
+
+func (s Session) String() string {
+	return fmt.Sprintf("Resume lesson %d at word %d/%d", s.LessonId, s.Current+1, len(s.Words))
+}
+
+// SaveSession stores the session, replacing any earlier one for the same lesson
+func SaveSession(session Session) error {
+	words, err := json.Marshal(session.Words)
+	if err != nil {
+		return err
+	}
+	_, err = db.DB.Exec(
+		`INSERT INTO sessions (user_id, lesson_id, current, input, words, updated_at) VALUES (?, ?, ?, ?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		ON CONFLICT (user_id, lesson_id) DO UPDATE SET current = excluded.current, input = excluded.input, words = excluded.words, updated_at = excluded.updated_at`,
+		session.UserId, session.LessonId, session.Current, session.Input, string(words))
+	return err
+}
+
+// GetSession returns the saved session for a lesson, or nil if there isn't one
+func GetSession(userId int64, lessonId int64) (*Session, error) {
+	return scanSession(db.DB.QueryRow(
+		"SELECT id, user_id, lesson_id, current, input, words, updated_at FROM sessions WHERE user_id = ? AND lesson_id = ?",
+		userId, lessonId))
+}
+
+// GetLatestSession returns the most recently saved session, or nil if there isn't one
+func GetLatestSession(userId int64) (*Session, error) {
+	return scanSession(db.DB.QueryRow(
+		"SELECT id, user_id, lesson_id, current, input, words, updated_at FROM sessions WHERE user_id = ? ORDER BY updated_at DESC, id DESC LIMIT 1",
+		userId))
+}
+
+// DeleteSession forgets the saved session for a lesson
+func DeleteSession(userId int64, lessonId int64) error {
+	_, err := db.DB.Exec("DELETE FROM sessions WHERE user_id = ? AND lesson_id = ?", userId, lessonId)
+	return err
+}
+
+// DeleteAllSessions forgets every user's saved sessions, they hold lessons as they were before the pack changed
+func DeleteAllSessions() error {
+	_, err := db.DB.Exec("DELETE FROM sessions")
+	return err
+}
+
+func scanSession(row *sql.Row) (*Session, error) {
+	var session Session
+	var words string
+	err := row.Scan(&session.Id, &session.UserId, &session.LessonId, &session.Current, &session.Input, &words, &session.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching session: %w", err)
+	}
+	if err := json.Unmarshal([]byte(words), &session.Words); err != nil {
+		return nil, fmt.Errorf("error parsing session: %w", err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/decarlec/lomo/db"
+)
+
+// TimedRun is the result of a timed run through a lesson
+type TimedRun struct {
+	Id             int64         `db:"id"`
+	UserId         int64         `db:"user_id"`
+	LessonId       int64         `db:"lesson_id"`
+	Score          int           `db:"score"`
+	Correct        int           `db:"correct"`  // words answered right in time
+	Total          int           `db:"total"`    // words in the lesson
+	Accuracy       int           `db:"accuracy"` // percentage of answers that were right, running out of time counts as wrong
+	AverageLatency time.Duration `db:"average_latency_ms"`
+	CreatedAt      time.Time     `db:"created_at"`
+	Runs           int           `db:"-"` // timed runs of the lesson, filled in by GetPersonalBests
+}
+
+// SaveTimedRun stores the result of a timed run
+func SaveTimedRun(run TimedRun) error {
+	_, err := db.DB.Exec(
+		`INSERT INTO timed_runs (user_id, lesson_id, score, correct, total, accuracy, average_latency_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		run.UserId, run.LessonId, run.Score, run.Correct, run.Total, run.Accuracy, run.AverageLatency.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("error saving timed run: %w", err)
+	}
+	return nil
+}
+
+// Best runs first, the earliest of equal scores
+const bestRunOrder = "score DESC, created_at, id"
+
+// GetPersonalBest returns the user's best timed run of a lesson, nil if they've never done one
+func GetPersonalBest(userId int64, lessonId int64) (*TimedRun, error) {
+	row := db.DB.QueryRow(
+		`SELECT id, user_id, lesson_id, score, correct, total, accuracy, average_latency_ms, created_at
+		FROM timed_runs WHERE user_id = ? AND lesson_id = ? ORDER BY `+bestRunOrder+` LIMIT 1`,
+		userId, lessonId)
+	run, err := scanTimedRun(row, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching personal best for lesson %d: %w", lessonId, err)
+	}
+	return &run, nil
+}
+
+// GetPersonalBests returns the user's best timed run of each lesson they've run, by lesson
+func GetPersonalBests(userId int64) ([]TimedRun, error) {
+	rows, err := db.DB.Query(
+		`SELECT id, user_id, lesson_id, score, correct, total, accuracy, average_latency_ms, created_at,
+			(SELECT COUNT(*) FROM timed_runs r WHERE r.user_id = t.user_id AND r.lesson_id = t.lesson_id)
+		FROM timed_runs t
+		WHERE user_id = ? AND id = (SELECT id FROM timed_runs b WHERE b.user_id = t.user_id AND b.lesson_id = t.lesson_id
+			ORDER BY `+bestRunOrder+` LIMIT 1)
+		ORDER BY lesson_id`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching personal bests: %w", err)
+	}
+	defer rows.Close()
+
+	bests := []TimedRun{}
+	for rows.Next() {
+		run, err := scanTimedRun(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning personal best: %w", err)
+		}
+		bests = append(bests, run)
+	}
+	return bests, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTimedRun reads a timed run, followed by the number of runs of its lesson when withRuns is set
+func scanTimedRun(row scanner, withRuns bool) (TimedRun, error) {
+	var run TimedRun
+	var latencyMs int64
+	dest := []any{&run.Id, &run.UserId, &run.LessonId, &run.Score, &run.Correct, &run.Total, &run.Accuracy, &latencyMs, &run.CreatedAt}
+	if withRuns {
+		dest = append(dest, &run.Runs)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return TimedRun{}, err
+	}
+	run.AverageLatency = time.Duration(latencyMs) * time.Millisecond
+	return run, nil
+}
@@ -0,0 +1,118 @@
+package pack
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+const usage = `usage: lomo pack <command> [arguments]
+
+commands:
+  list                     list installed packs
+  install <dir>            install the pack bundle in dir
+  upgrade [-force] <dir>   replace an installed pack with a newer version, keeping your progress
+  remove <name>            remove an installed pack, keeping your progress
+  sentences [-pack name] <file>
+                           import example sentences from a tab separated file
+`
+
+// Command runs the `lomo pack` subcommands
+func Command(args []string) error {
+	// Opening the database logs, which doesn't belong in the command's output
+	log.SetOutput(io.Discard)
+	if err := EnsureBuiltin(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fmt.Print(usage)
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		return listCommand()
+	case "install":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: lomo pack install <dir>")
+		}
+		p, err := Install(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s %s\n", p.Name, p.Version)
+	case "upgrade":
+		fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+		force := fs.Bool("force", false, "install even if the version is not newer")
+		if err := fs.Parse(args[1:]); err == flag.ErrHelp {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: lomo pack upgrade [-force] <dir>")
+		}
+		p, err := Upgrade(fs.Arg(0), *force)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Upgraded %s to %s\n", p.Name, p.Version)
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: lomo pack remove <name>")
+		}
+		if err := Remove(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s, your progress has been kept\n", args[1])
+	case "sentences":
+		fs := flag.NewFlagSet("sentences", flag.ContinueOnError)
+		name := fs.String("pack", "", "pack to add the sentences to, the default pack if not given")
+		if err := fs.Parse(args[1:]); err == flag.ErrHelp {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: lomo pack sentences [-pack name] <file>")
+		}
+		p, err := Resolve(*name)
+		if err != nil {
+			return err
+		}
+		sentences, words, err := ImportSentences(p, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d sentences for %d words of %s\n", sentences, words, p.Name)
+	default:
+		fmt.Print(usage)
+		return fmt.Errorf("unknown pack command %q", args[0])
+	}
+	return nil
+}
+
+func listCommand() error {
+	packs, err := List()
+	if err != nil {
+		return err
+	}
+	if len(packs) == 0 {
+		fmt.Println("No packs installed.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tLANGUAGES\tWORDS\tLESSONS\tLICENSE")
+	for _, p := range packs {
+		words, lessons, err := p.Counts()
+		if err != nil {
+			return fmt.Errorf("pack %s: %w", p.Name, err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s-%s\t%d\t%d\t%s\n", p.Name, p.Version, p.From, p.To, words, lessons, p.License)
+	}
+	return w.Flush()
+}
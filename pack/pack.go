@@ -0,0 +1,502 @@
+package pack
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decarlec/lomo/db"
+	"github.com/decarlec/lomo/models"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Schema creates the tables a pack database must contain
+//
+//go:embed schema.sql
+var Schema string
+
+const (
+	ManifestFile = "manifest.json"
+	DBFile       = "pack.db"
+	WordsFile    = "words.tsv"
+	LessonsFile  = "lessons.tsv"
+
+	FormatSQLite = "sqlite"
+	FormatTSV    = "tsv"
+
+	// Name of the pack built from the embedded words.db
+	BuiltinName = "es-en"
+	// Bump whenever the embedded words.db changes so installed copies get refreshed
+	BuiltinVersion = "1.2.0"
+)
+
+// Manifest describes a language pack
+type Manifest struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	From        string `json:"from"` // language being learned
+	To          string `json:"to"`   // language of the translations
+	License     string `json:"license"`
+	Description string `json:"description,omitempty"`
+	Format      string `json:"format"` // "sqlite" or "tsv"
+}
+
+// Pack is an installed language pack
+type Pack struct {
+	Manifest
+	Dir string
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// wordIdsPattern is a lesson's word ids, comma separated integers such as 1,2,3
+var wordIdsPattern = regexp.MustCompile(`^\s*\d+\s*(,\s*\d+\s*)*$`)
+
+var ErrNotInstalled = errors.New("pack is not installed")
+
+func builtinManifest() Manifest {
+	return Manifest{
+		Name:        BuiltinName,
+		Version:     BuiltinVersion,
+		From:        "es",
+		To:          "en",
+		License:     "CC-BY-SA",
+		Description: "The 1000 most common Spanish words",
+		Format:      FormatSQLite,
+	}
+}
+
+// DBPath is the pack's words and lessons database
+func (p Pack) DBPath() string {
+	return filepath.Join(p.Dir, DBFile)
+}
+
+// ProgressPath is where the user's progress for this pack is stored.
+// It lives outside the pack directory so upgrading or removing a pack keeps it.
+func (p Pack) ProgressPath() (string, error) {
+	dir, err := db.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "progress", p.Name+".db"), nil
+}
+
+// Validate checks that the manifest has everything needed to install it
+func (m Manifest) Validate() error {
+	if !namePattern.MatchString(m.Name) {
+		return fmt.Errorf("invalid pack name %q: use lowercase letters, digits, - and _", m.Name)
+	}
+	if _, err := parseVersion(m.Version); err != nil {
+		return err
+	}
+	if m.From == "" || m.To == "" {
+		return fmt.Errorf("pack %s: from and to languages are required", m.Name)
+	}
+	if m.Format != FormatSQLite && m.Format != FormatTSV {
+		return fmt.Errorf("pack %s: unknown format %q, expected %q or %q", m.Name, m.Format, FormatSQLite, FormatTSV)
+	}
+	return nil
+}
+
+func packsDir() (string, error) {
+	dir, err := db.DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "packs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	return dir, nil
+}
+
+func readManifest(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return m, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return m, m.Validate()
+}
+
+func writeManifest(dir string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFile), append(data, '\n'), 0o644)
+}
+
+// List returns all installed packs sorted by name
+func List() ([]Pack, error) {
+	dir, err := packsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packs directory: %w", err)
+	}
+
+	packs := []Pack{}
+	for _, entry := range entries {
+		// Skip anything half installed
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		m, err := readManifest(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("pack %s: %w", entry.Name(), err)
+		}
+		packs = append(packs, Pack{Manifest: m, Dir: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// Get returns the installed pack with the given name
+func Get(name string) (Pack, error) {
+	dir, err := packsDir()
+	if err != nil {
+		return Pack{}, err
+	}
+	dir = filepath.Join(dir, name)
+	m, err := readManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return Pack{}, fmt.Errorf("%s: %w", name, ErrNotInstalled)
+	}
+	if err != nil {
+		return Pack{}, err
+	}
+	return Pack{Manifest: m, Dir: dir}, nil
+}
+
+// Resolve returns the named pack, or the default one if name is empty
+func Resolve(name string) (Pack, error) {
+	if name != "" {
+		return Get(name)
+	}
+	packs, err := List()
+	if err != nil {
+		return Pack{}, err
+	}
+	for _, p := range packs {
+		if p.Name == BuiltinName {
+			return p, nil
+		}
+	}
+	if len(packs) == 0 {
+		return Pack{}, fmt.Errorf("no packs installed, install one with `lomo pack install <path>`")
+	}
+	return packs[0], nil
+}
+
+// EnsureBuiltin installs the embedded pack on first run, and refreshes it when lomo ships a newer one
+func EnsureBuiltin() error {
+	packs, err := List()
+	if err != nil {
+		return err
+	}
+	writeBuiltin := func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, DBFile), db.Embedded(), 0o644)
+	}
+	builtin := builtinManifest()
+	for _, p := range packs {
+		if p.Name != BuiltinName {
+			continue
+		}
+		newer, err := isNewer(builtin.Version, p.Version)
+		if err != nil || !newer {
+			return err
+		}
+		return replace(builtin, writeBuiltin)
+	}
+	// Only install it when nothing else is, so removing it sticks
+	if len(packs) > 0 {
+		return nil
+	}
+	return replace(builtin, writeBuiltin)
+}
+
+// Install installs the pack bundle found in src
+func Install(src string) (Pack, error) {
+	m, err := readManifest(src)
+	if err != nil {
+		return Pack{}, err
+	}
+	if _, err := Get(m.Name); err == nil {
+		return Pack{}, fmt.Errorf("pack %s is already installed, use `lomo pack upgrade`", m.Name)
+	}
+	return install(src, m, "")
+}
+
+// Upgrade replaces an installed pack with the bundle in src. Unless force is set the bundle must be newer.
+// Word ids must keep meaning the same words, progress refers to them.
+func Upgrade(src string, force bool) (Pack, error) {
+	m, err := readManifest(src)
+	if err != nil {
+		return Pack{}, err
+	}
+	installed, err := Get(m.Name)
+	if err != nil {
+		return Pack{}, err
+	}
+	newer, err := isNewer(m.Version, installed.Version)
+	if err != nil {
+		return Pack{}, err
+	}
+	if !newer && !force {
+		return Pack{}, fmt.Errorf("pack %s %s is not newer than installed %s", m.Name, m.Version, installed.Version)
+	}
+	return install(src, m, installed.DBPath())
+}
+
+// Remove deletes an installed pack. The user's progress for it is kept.
+func Remove(name string) error {
+	p, err := Get(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(p.Dir)
+}
+
+// install installs the bundle in src. When previous is the database of the version it replaces,
+// the new version's word ids are checked against it.
+func install(src string, m Manifest, previous string) (Pack, error) {
+	err := replace(m, func(dir string) error {
+		switch m.Format {
+		case FormatSQLite:
+			if err := copyFile(filepath.Join(src, DBFile), filepath.Join(dir, DBFile)); err != nil {
+				return err
+			}
+		case FormatTSV:
+			if err := buildFromTSV(src, filepath.Join(dir, DBFile)); err != nil {
+				return err
+			}
+		}
+		if err := checkDB(filepath.Join(dir, DBFile)); err != nil {
+			return err
+		}
+		if previous == "" {
+			return nil
+		}
+		return checkStableIds(previous, filepath.Join(dir, DBFile))
+	})
+	if err != nil {
+		return Pack{}, err
+	}
+	return Get(m.Name)
+}
+
+// replace builds a pack in a staging directory with fill, then swaps it into place
+func replace(m Manifest, fill func(dir string) error) error {
+	dir, err := packsDir()
+	if err != nil {
+		return err
+	}
+	staging := filepath.Join(dir, "."+m.Name+".new")
+	old := filepath.Join(dir, "."+m.Name+".old")
+	target := filepath.Join(dir, m.Name)
+
+	os.RemoveAll(staging)
+	if err := os.MkdirAll(staging, 0o755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := fill(staging); err != nil {
+		return err
+	}
+	// Installed packs are always stored as sqlite
+	m.Format = FormatSQLite
+	if err := writeManifest(staging, m); err != nil {
+		return err
+	}
+
+	os.RemoveAll(old)
+	if err := os.Rename(target, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(staging, target); err != nil {
+		os.Rename(old, target)
+		return err
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	return tidyProgress(Pack{Manifest: m, Dir: target})
+}
+
+// tidyProgress brings the user's progress in line with a pack whose words were just replaced.
+// Example sentences were matched against the old words and saved sessions hold the old lessons,
+// so both are dropped along with the states of words the pack no longer has.
+func tidyProgress(p Pack) error {
+	progress, err := p.ProgressPath()
+	if err != nil {
+		return err
+	}
+	// There's nothing to tidy for a pack that has never been opened
+	if _, err := os.Stat(progress); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := db.InitDB(p.DBPath(), progress); err != nil {
+		return err
+	}
+	defer db.DB.Close()
+	if err := models.ReplaceSentences(nil); err != nil {
+		return fmt.Errorf("failed to clear example sentences: %w", err)
+	}
+	if err := models.DeleteAllSessions(); err != nil {
+		return fmt.Errorf("failed to clear saved lessons: %w", err)
+	}
+	if err := models.DeleteMissingWordStates(); err != nil {
+		return fmt.Errorf("failed to clear progress of removed words: %w", err)
+	}
+	return nil
+}
+
+// checkStableIds makes sure every word id in both databases is the same word in each
+func checkStableIds(previous string, path string) error {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// One connection, the attached database only exists on it
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("ATTACH DATABASE ? AS previous", previous); err != nil {
+		return fmt.Errorf("failed to open installed pack: %w", err)
+	}
+
+	var id int64
+	var was, now string
+	err = conn.QueryRow(`SELECT w.id, p.spanish, w.spanish FROM words w JOIN previous.words p ON p.id = w.id
+		WHERE p.spanish != w.spanish ORDER BY w.id LIMIT 1`).Scan(&id, &was, &now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("word %d was %q and is now %q, word ids must stay the same between versions of a pack", id, was, now)
+}
+
+func copyFile(src string, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read pack database: %w", err)
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+// checkDB makes sure a pack database has the tables lomo reads
+func checkDB(path string) error {
+	words, lessons, err := counts(path)
+	if err != nil {
+		return fmt.Errorf("invalid pack database: %w", err)
+	}
+	if words == 0 || lessons == 0 {
+		return fmt.Errorf("invalid pack database: it has %d words and %d lessons", words, lessons)
+	}
+	if err := checkLessons(path); err != nil {
+		return fmt.Errorf("invalid pack database: %w", err)
+	}
+	return nil
+}
+
+// checkLessons makes sure every lesson's word ids are a list of integers
+func checkLessons(path string) error {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("SELECT id, COALESCE(word_ids, '') FROM lessons")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var wordIds string
+		if err := rows.Scan(&id, &wordIds); err != nil {
+			return err
+		}
+		if !wordIdsPattern.MatchString(wordIds) {
+			return fmt.Errorf("lesson %d has word_ids %q, expected comma separated word ids", id, wordIds)
+		}
+	}
+	return rows.Err()
+}
+
+// Counts returns the number of words and lessons in the pack
+func (p Pack) Counts() (int, int, error) {
+	return counts(p.DBPath())
+}
+
+func counts(path string) (int, int, error) {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	var words, lessons int
+	if err := conn.QueryRow("SELECT count(*) FROM words").Scan(&words); err != nil {
+		return 0, 0, err
+	}
+	if err := conn.QueryRow("SELECT count(*) FROM lessons").Scan(&lessons); err != nil {
+		return 0, 0, err
+	}
+	return words, lessons, nil
+}
+
+func parseVersion(version string) ([]int, error) {
+	parts := strings.Split(version, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q: expected numbers separated by dots, e.g. 1.2.0", version)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// isNewer reports whether version a is newer than version b
+func isNewer(a string, b string) (bool, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return false, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return false, err
+	}
+	for i := range max(len(va), len(vb)) {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			return x > y, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS words (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    spanish TEXT NOT NULL UNIQUE,
+    english_primary TEXT
+);
+
+CREATE TABLE IF NOT EXISTS senses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    gloss TEXT NOT NULL,
+    part_of_speech TEXT NOT NULL,
+    gender TEXT NOT NULL DEFAULT '',
+    plural BOOLEAN NOT NULL DEFAULT 0,
+    label TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS senses_word_id ON senses (word_id, position);
+
+CREATE TABLE IF NOT EXISTS lessons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_ids TEXT NOT NULL
+);
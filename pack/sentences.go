@@ -0,0 +1,113 @@
+package pack
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/decarlec/lomo/db"
+	"github.com/decarlec/lomo/models"
+)
+
+// Example sentences kept for each word, the shortest found
+const sentencesPerWord = 3
+
+// ImportSentences replaces the pack's example sentences with the ones in path that use its words.
+// Lines are tab separated, either "spanish<TAB>english" or Tatoeba's sentence pairs export,
+// "id<TAB>spanish<TAB>id<TAB>english". Returns how many sentences and words were matched.
+func ImportSentences(p Pack, path string) (int, int, error) {
+	progress, err := p.ProgressPath()
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := db.InitDB(p.DBPath(), progress); err != nil {
+		return 0, 0, err
+	}
+	defer db.DB.Close()
+
+	words, err := models.GetAllWords()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read pack words: %w", err)
+	}
+	// Words by their first token, some words are more than one token long
+	byFirst := map[string][]models.Word{}
+	for _, word := range words {
+		tokens := models.Tokenize(word.Spanish)
+		if len(tokens) > 0 {
+			byFirst[tokens[0].Text] = append(byFirst[tokens[0].Text], word)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	found := map[int64][]models.Sentence{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		sentence, err := parseSentence(line)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s line %d: %w", filepath.Base(path), lineNumber, err)
+		}
+		seen := map[int64]bool{}
+		for _, token := range models.Tokenize(sentence.Spanish) {
+			for _, word := range byFirst[token.Text] {
+				if seen[word.Id] {
+					continue
+				}
+				if _, _, ok := sentence.Find(word.Spanish); !ok {
+					continue
+				}
+				seen[word.Id] = true
+				sentence.WordId = word.Id
+				found[word.Id] = keepShortest(found[word.Id], sentence)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	sentences := []models.Sentence{}
+	for _, word := range words {
+		sentences = append(sentences, found[word.Id]...)
+	}
+	if err := models.ReplaceSentences(sentences); err != nil {
+		return 0, 0, err
+	}
+	return len(sentences), len(found), nil
+}
+
+func parseSentence(line string) (models.Sentence, error) {
+	fields := strings.Split(line, "\t")
+	switch len(fields) {
+	case 2:
+		return models.Sentence{Spanish: strings.TrimSpace(fields[0]), English: strings.TrimSpace(fields[1])}, nil
+	case 4:
+		return models.Sentence{Spanish: strings.TrimSpace(fields[1]), English: strings.TrimSpace(fields[3])}, nil
+	}
+	return models.Sentence{}, fmt.Errorf("expected 2 or 4 columns, got %d", len(fields))
+}
+
+// keepShortest adds sentence to sentences, keeping the sentencesPerWord shortest.
+// Short sentences are easier to read mid lesson.
+func keepShortest(sentences []models.Sentence, sentence models.Sentence) []models.Sentence {
+	if slices.ContainsFunc(sentences, func(s models.Sentence) bool { return s.Spanish == sentence.Spanish }) {
+		return sentences
+	}
+	sentences = append(sentences, sentence)
+	slices.SortStableFunc(sentences, func(a, b models.Sentence) int { return len(a.Spanish) - len(b.Spanish) })
+	return sentences[:min(len(sentences), sentencesPerWord)]
+}
@@ -0,0 +1,120 @@
+package pack
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/decarlec/lomo/models"
+)
+
+var (
+	wordsHeader   = []string{"id", "spanish", "english_primary", "english_translations", "word_type"}
+	lessonsHeader = []string{"id", "word_ids"}
+)
+
+// buildFromTSV creates a pack database at dst from the words.tsv and lessons.tsv files in src
+func buildFromTSV(src string, dst string) error {
+	words, err := readTSV(filepath.Join(src, WordsFile), wordsHeader)
+	if err != nil {
+		return err
+	}
+	lessons, err := readTSV(filepath.Join(src, LessonsFile), lessonsHeader)
+	if err != nil {
+		return err
+	}
+
+	conn, err := sql.Open("sqlite3", dst)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(Schema); err != nil {
+		return fmt.Errorf("failed to create pack schema: %w", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, fields := range words {
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s row %d: invalid id %q", WordsFile, i+2, fields[0])
+		}
+		// Translations and their types are | separated in the bundle, each translation is a sense
+		translations := strings.Split(fields[3], "|")
+		types := strings.Split(fields[4], "|")
+		if len(types) != 1 && len(types) != len(translations) {
+			return fmt.Errorf("%s row %d: expected 1 or %d word types, got %d", WordsFile, i+2, len(translations), len(types))
+		}
+		word := models.Word{Id: id, Spanish: fields[1], EnglishPrimary: fields[2]}
+		for j, gloss := range translations {
+			sense := models.ParseWordType(types[min(j, len(types)-1)])
+			sense.Gloss = gloss
+			word.Senses = append(word.Senses, sense)
+		}
+		if err := models.InsertWord(tx, word); err != nil {
+			return fmt.Errorf("%s row %d: %w", WordsFile, i+2, err)
+		}
+	}
+
+	for i, fields := range lessons {
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s row %d: invalid id %q", LessonsFile, i+2, fields[0])
+		}
+		for wordId := range strings.SplitSeq(fields[1], ",") {
+			if _, err := strconv.ParseInt(wordId, 10, 64); err != nil {
+				return fmt.Errorf("%s row %d: invalid word id %q", LessonsFile, i+2, wordId)
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO lessons (id, word_ids) VALUES (?, ?)", id, fields[1]); err != nil {
+			return fmt.Errorf("%s row %d: %w", LessonsFile, i+2, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// readTSV reads a tab separated file, checking its header and returning the remaining rows
+func readTSV(path string, header []string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	rows := [][]string{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if lineNumber == 1 {
+			if strings.Join(fields, "\t") != strings.Join(header, "\t") {
+				return nil, fmt.Errorf("%s: invalid header, expected %q", filepath.Base(path), strings.Join(header, "\t"))
+			}
+			continue
+		}
+		if len(fields) != len(header) {
+			return nil, fmt.Errorf("%s line %d: expected %d columns, got %d", filepath.Base(path), lineNumber, len(header), len(fields))
+		}
+		rows = append(rows, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return rows, nil
+}
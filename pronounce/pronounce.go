@@ -0,0 +1,356 @@
+// Package pronounce works out how Spanish words are said from their spelling: their syllables,
+// which one is stressed and an IPA transcription. Transcriptions are of neutral Latin American
+// Spanish, c and z before e or i are s and ll sounds like y.
+package pronounce
+
+import (
+	"strings"
+	"unicode"
+)
+
+// unit is a letter or a group of letters making one sound, like ch, rr or the qu of queso
+type unit struct {
+	text  string
+	vowel bool
+}
+
+// word is a word split into syllables of units
+type word struct {
+	syllables [][]unit
+	stressed  int
+}
+
+// Syllables splits a word into its syllables, palabra is pa, la and bra
+func Syllables(text string) []string {
+	w := parse(text)
+	syllables := make([]string, len(w.syllables))
+	for i, syllable := range w.syllables {
+		for _, u := range syllable {
+			syllables[i] += u.text
+		}
+	}
+	return syllables
+}
+
+// Stressed returns the index of a word's stressed syllable
+func Stressed(text string) int {
+	return parse(text).stressed
+}
+
+// Guide shows a word or phrase split into syllables with the stressed ones in capitals, pa-LA-bra
+func Guide(text string) string {
+	words := []string{}
+	for _, field := range fields(text) {
+		syllables := Syllables(field)
+		if len(syllables) > 1 {
+			stressed := Stressed(field)
+			syllables[stressed] = strings.ToUpper(syllables[stressed])
+		}
+		words = append(words, strings.Join(syllables, "-"))
+	}
+	return strings.Join(words, " ")
+}
+
+// IPA transcribes a word or phrase, palabra is paˈlaβɾa
+func IPA(text string) string {
+	words := []string{}
+	prev := ""
+	for _, field := range fields(text) {
+		w := parse(field)
+		ipa := w.ipa(prev)
+		if last := len(words) - 1; last >= 0 {
+			if before, ok := strings.CutSuffix(words[last], "n"); ok {
+				words[last] = before + nasal(strings.TrimPrefix(ipa, "ˈ"))
+			}
+		}
+		words = append(words, ipa)
+		last := w.syllables[len(w.syllables)-1]
+		prev = last[len(last)-1].text
+	}
+	return strings.Join(words, " ")
+}
+
+// fields splits text into its words, leaving out anything that isn't a letter
+func fields(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouáéíóúü", r)
+}
+
+// strong vowels are a, e, o and accented i or u, two of them next to each other are separate syllables
+func strong(u unit) bool {
+	return u.vowel && strings.ContainsAny(u.text, "aeoáéóíú")
+}
+
+func accented(u unit) bool {
+	return strings.ContainsAny(u.text, "áéíóú")
+}
+
+// frontVowel reports whether r is e or i, which soften c and g
+func frontVowel(r rune) bool {
+	return strings.ContainsRune("eiéí", r)
+}
+
+// units splits a word into the units of its spelling
+func units(text string) []unit {
+	runes := []rune(strings.ToLower(text))
+	at := func(i int) rune {
+		if i < len(runes) {
+			return runes[i]
+		}
+		return 0
+	}
+
+	result := []unit{}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case !unicode.IsLetter(r):
+			continue
+		case r == 'c' && at(i+1) == 'h', r == 'l' && at(i+1) == 'l', r == 'r' && at(i+1) == 'r':
+			result = append(result, unit{text: string(runes[i : i+2])})
+			i++
+		// The u of que, qui, gue and gui is silent
+		case (r == 'q' || r == 'g') && at(i+1) == 'u' && frontVowel(at(i+2)):
+			result = append(result, unit{text: string(runes[i : i+2])})
+			i++
+		// Y is a vowel unless a vowel follows it, as in hoy or y
+		case r == 'y':
+			result = append(result, unit{text: "y", vowel: !isVowel(at(i + 1))})
+		default:
+			result = append(result, unit{text: string(r), vowel: isVowel(r)})
+		}
+	}
+	return result
+}
+
+// inseparable reports whether two consonants always start a syllable together, like pl or tr
+func inseparable(first unit, second unit) bool {
+	return strings.Contains("pbfcgktd", first.text) && len(first.text) == 1 &&
+		(second.text == "l" || second.text == "r") && !(first.text == "d" && second.text == "l")
+}
+
+// parse splits a word into syllables and finds its stress
+func parse(text string) word {
+	us := units(text)
+	if len(us) == 0 {
+		return word{syllables: [][]unit{{{text: text}}}}
+	}
+
+	// Each syllable has a run of vowels at its heart, split where two strong vowels meet
+	type nucleus struct{ start, end int }
+	nuclei := []nucleus{}
+	for i, u := range us {
+		if !u.vowel {
+			continue
+		}
+		last := len(nuclei) - 1
+		if last >= 0 && nuclei[last].end == i && !(strong(us[i-1]) && strong(u)) {
+			nuclei[last].end = i + 1
+			continue
+		}
+		nuclei = append(nuclei, nucleus{i, i + 1})
+	}
+	if len(nuclei) == 0 {
+		return word{syllables: [][]unit{us}}
+	}
+
+	// The consonants between two nuclei go to the next syllable, except those that can't start one
+	starts := []int{0}
+	for k := 1; k < len(nuclei); k++ {
+		cluster := us[nuclei[k-1].end:nuclei[k].start]
+		n := len(cluster)
+		keep := 0
+		switch {
+		case n == 2 && !inseparable(cluster[0], cluster[1]):
+			keep = 1
+		case n >= 3 && inseparable(cluster[n-2], cluster[n-1]):
+			keep = n - 2
+		case n >= 3:
+			keep = n - 1
+		}
+		starts = append(starts, nuclei[k-1].end+keep)
+	}
+
+	w := word{}
+	for k, start := range starts {
+		end := len(us)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		w.syllables = append(w.syllables, us[start:end])
+	}
+	w.stressed = w.stress()
+	return w
+}
+
+// stress finds the stressed syllable, the one with a written accent or otherwise the second to
+// last in words ending in a vowel, n or s and the last in the rest
+func (w word) stress() int {
+	for i, syllable := range w.syllables {
+		for _, u := range syllable {
+			if accented(u) {
+				return i
+			}
+		}
+	}
+	last := w.syllables[len(w.syllables)-1]
+	ending := last[len(last)-1].text
+	if len(w.syllables) > 1 && strings.Contains("aeiouns", ending) {
+		return len(w.syllables) - 2
+	}
+	return len(w.syllables) - 1
+}
+
+// plain drops a vowel's accent or diaeresis
+var plain = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "y", "i")
+
+// ipa transcribes the word, prev is the last unit of the word before it in a phrase
+func (w word) ipa(prev string) string {
+	type sound struct {
+		phone    string
+		syllable int
+	}
+	sounds := []sound{}
+	flat := []unit{}
+	syllableOf := []int{}
+	for i, syllable := range w.syllables {
+		flat = append(flat, syllable...)
+		for range syllable {
+			syllableOf = append(syllableOf, i)
+		}
+	}
+
+	for i, u := range flat {
+		next := rune(0)
+		if i+1 < len(flat) {
+			next = []rune(flat[i+1].text)[0]
+		}
+		before := prev
+		if i > 0 {
+			before = flat[i-1].text
+		}
+		var phone string
+		if u.vowel {
+			phone = w.vowel(syllableOf[i], u)
+		} else {
+			phone = consonant(u.text, before, next, i == 0)
+		}
+		sounds = append(sounds, sound{phone, syllableOf[i]})
+	}
+
+	for i := 0; i+1 < len(sounds); i++ {
+		if sounds[i].phone == "n" {
+			sounds[i].phone = nasal(sounds[i+1].phone)
+		}
+	}
+
+	var b strings.Builder
+	for i, s := range sounds {
+		if len(w.syllables) > 1 && s.syllable == w.stressed && (i == 0 || sounds[i-1].syllable != s.syllable) {
+			b.WriteString("ˈ")
+		}
+		b.WriteString(s.phone)
+	}
+	return b.String()
+}
+
+// nasal is how n sounds before following, it takes on the place of the consonant after it as in
+// un beso or tengo
+func nasal(following string) string {
+	switch {
+	case strings.HasPrefix(following, "b") || strings.HasPrefix(following, "β") ||
+		strings.HasPrefix(following, "p") || strings.HasPrefix(following, "m"):
+		return "m"
+	case strings.HasPrefix(following, "k") || strings.HasPrefix(following, "g") ||
+		strings.HasPrefix(following, "ɣ") || strings.HasPrefix(following, "x"):
+		return "ŋ"
+	}
+	return "n"
+}
+
+// vowel transcribes a vowel of a syllable. With other vowels around it i and u glide, j and w
+// before the syllable's main vowel, i̯ and u̯ after it.
+func (w word) vowel(syllable int, u unit) string {
+	vowels := []unit{}
+	position := 0
+	for _, other := range w.syllables[syllable] {
+		if other.vowel {
+			if other == u {
+				position = len(vowels)
+			}
+			vowels = append(vowels, other)
+		}
+	}
+	phone := plain.Replace(u.text)
+	if len(vowels) == 1 {
+		return phone
+	}
+
+	// The main vowel is the first strong one, or the last of two weak ones as in ciudad. A y
+	// ending the syllable is always a glide, muy is mui̯.
+	main := len(vowels) - 1
+	if vowels[main].text == "y" {
+		main--
+	}
+	for i, v := range vowels {
+		if strong(v) {
+			main = i
+			break
+		}
+	}
+	switch {
+	case position < main && phone == "i":
+		return "j"
+	case position < main && phone == "u":
+		return "w"
+	case position > main && (phone == "i" || phone == "u"):
+		return phone + "̯"
+	}
+	return phone
+}
+
+// consonant transcribes a consonant, before is the unit before it and next the letter after it.
+// B, d and g are stops at the start or after a nasal, l for d, and soften between vowels.
+func consonant(text string, before string, next rune, first bool) string {
+	stop := before == "" || before == "m" || before == "n"
+	switch text {
+	case "b", "v":
+		if stop {
+			return "b"
+		}
+		return "β"
+	case "d":
+		if stop || before == "l" {
+			return "d"
+		}
+		return "ð"
+	case "g", "gu":
+		if text == "g" && frontVowel(next) {
+			return "x"
+		}
+		if stop {
+			return "g"
+		}
+		return "ɣ"
+	case "c":
+		if frontVowel(next) {
+			return "s"
+		}
+		return "k"
+	case "r":
+		if first || before == "n" || before == "l" || before == "s" {
+			return "r"
+		}
+		return "ɾ"
+	}
+	sounds := map[string]string{
+		"ch": "tʃ", "qu": "k", "z": "s", "j": "x", "h": "", "ll": "ʝ", "y": "ʝ", "ñ": "ɲ", "rr": "r", "x": "ks",
+	}
+	if phone, ok := sounds[text]; ok {
+		return phone
+	}
+	return text
+}
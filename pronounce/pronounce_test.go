@@ -0,0 +1,69 @@
+package pronounce
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPronounce(t *testing.T) {
+	tests := []struct {
+		word      string
+		syllables []string
+		stressed  int
+		guide     string
+		ipa       string
+	}{
+		{"palabra", []string{"pa", "la", "bra"}, 1, "pa-LA-bra", "paˈlaβɾa"},
+		{"ciudad", []string{"ciu", "dad"}, 1, "ciu-DAD", "sjuˈðað"},
+		{"cuidado", []string{"cui", "da", "do"}, 1, "cui-DA-do", "kwiˈðaðo"},
+		{"muy", []string{"muy"}, 0, "muy", "mui̯"},
+		{"hoy", []string{"hoy"}, 0, "hoy", "oi̯"},
+		{"guerra", []string{"gue", "rra"}, 0, "GUE-rra", "ˈgera"},
+		{"pingüino", []string{"pin", "güi", "no"}, 1, "pin-GÜI-no", "piŋˈgwino"},
+		{"Uruguay", []string{"u", "ru", "guay"}, 2, "u-ru-GUAY", "uɾuˈɣwai̯"},
+		{"árbol", []string{"ár", "bol"}, 0, "ÁR-bol", "ˈaɾβol"},
+		{"país", []string{"pa", "ís"}, 1, "pa-ÍS", "paˈis"},
+		{"hablar", []string{"ha", "blar"}, 1, "ha-BLAR", "aˈβlaɾ"},
+		{"inglés", []string{"in", "glés"}, 1, "in-GLÉS", "iŋˈgles"},
+		{"construir", []string{"cons", "truir"}, 1, "cons-TRUIR", "konsˈtɾwiɾ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Syllables(tt.word); !slices.Equal(got, tt.syllables) {
+				t.Errorf("Syllables(%q) = %q, want %q", tt.word, got, tt.syllables)
+			}
+			if got := Stressed(tt.word); got != tt.stressed {
+				t.Errorf("Stressed(%q) = %d, want %d", tt.word, got, tt.stressed)
+			}
+			if got := Guide(tt.word); got != tt.guide {
+				t.Errorf("Guide(%q) = %q, want %q", tt.word, got, tt.guide)
+			}
+			if got := IPA(tt.word); got != tt.ipa {
+				t.Errorf("IPA(%q) = %q, want %q", tt.word, got, tt.ipa)
+			}
+		})
+	}
+}
+
+func TestPronouncePhrase(t *testing.T) {
+	tests := []struct {
+		phrase string
+		guide  string
+		ipa    string
+	}{
+		// Each word keeps its own syllables and stress, consonants soften after the word before
+		{"el agua", "el A-gua", "el ˈaɣwa"},
+		{"un beso", "un BE-so", "um ˈbeso"},
+		{"la verdad", "la ver-DAD", "la βeɾˈðað"},
+		{"¡Muy bien!", "muy bien", "mui̯ βjen"},
+		{"un gato", "un GA-to", "uŋ ˈgato"},
+	}
+	for _, tt := range tests {
+		if got := Guide(tt.phrase); got != tt.guide {
+			t.Errorf("Guide(%q) = %q, want %q", tt.phrase, got, tt.guide)
+		}
+		if got := IPA(tt.phrase); got != tt.ipa {
+			t.Errorf("IPA(%q) = %q, want %q", tt.phrase, got, tt.ipa)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Enterer is implemented by screens that need to do something when they become the current screen
+type Enterer interface {
+	Enter() (tea.Model, tea.Cmd)
+}
+
+// Leaver is implemented by screens that need to do something when they stop being the current screen,
+// either because another screen was pushed on top or because they were popped
+type Leaver interface {
+	Leave() (tea.Model, tea.Cmd)
+}
+
+// Router is a stack of screens, only the top one receives messages and is rendered
+type Router struct {
+	stack []tea.Model
+	size  *tea.WindowSizeMsg // the latest window size, sent to screens as they are pushed
+}
+
+// New creates a router with root at the bottom of the stack. Root is never popped.
+func New(root tea.Model) Router {
+	return Router{stack: []tea.Model{root}}
+}
+
+// Current returns the screen on top of the stack
+func (r Router) Current() tea.Model {
+	return r.stack[len(r.stack)-1]
+}
+
+// Depth is the number of screens on the stack
+func (r Router) Depth() int {
+	return len(r.stack)
+}
+
+// Push leaves the current screen, then initializes, sizes and enters screen
+func (r *Router) Push(screen tea.Model) tea.Cmd {
+	leaveCmd := r.leave()
+	r.stack = append(r.stack, screen)
+	initCmd := screen.Init()
+	var sizeCmd tea.Cmd
+	if r.size != nil {
+		sizeCmd = r.Update(*r.size)
+	}
+	return tea.Batch(leaveCmd, initCmd, sizeCmd, r.enter())
+}
+
+// Pop leaves and removes the current screen, entering the one below it.
+// The popped screen is returned so callers can hold on to it.
+func (r *Router) Pop() (tea.Model, tea.Cmd) {
+	if len(r.stack) == 1 {
+		return nil, nil
+	}
+	leaveCmd := r.leave()
+	popped := r.Current()
+	r.stack = r.stack[:len(r.stack)-1]
+	return popped, tea.Batch(leaveCmd, r.enter())
+}
+
+// PopToRoot pops every screen above the root, returning them top first
+func (r *Router) PopToRoot() ([]tea.Model, tea.Cmd) {
+	if len(r.stack) == 1 {
+		return nil, nil
+	}
+	// Only the current screen is left, the ones under it already were when they were covered
+	leaveCmd := r.leave()
+	popped := slices.Clone(r.stack[1:])
+	slices.Reverse(popped)
+	r.stack = r.stack[:1]
+	return popped, tea.Batch(leaveCmd, r.enter())
+}
+
+// Resize sends the window size to every screen, so covered screens fit when they are shown again
+func (r *Router) Resize(msg tea.WindowSizeMsg) tea.Cmd {
+	r.size = &msg
+	cmds := make([]tea.Cmd, len(r.stack))
+	for i, screen := range r.stack {
+		r.stack[i], cmds[i] = screen.Update(msg)
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update sends msg to the current screen
+func (r *Router) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	r.stack[len(r.stack)-1], cmd = r.Current().Update(msg)
+	return cmd
+}
+
+func (r Router) View() string {
+	return r.Current().View()
+}
+
+func (r *Router) enter() tea.Cmd {
+	screen, ok := r.Current().(Enterer)
+	if !ok {
+		return nil
+	}
+	var cmd tea.Cmd
+	r.stack[len(r.stack)-1], cmd = screen.Enter()
+	return cmd
+}
+
+func (r *Router) leave() tea.Cmd {
+	screen, ok := r.Current().(Leaver)
+	if !ok {
+		return nil
+	}
+	var cmd tea.Cmd
+	r.stack[len(r.stack)-1], cmd = screen.Leave()
+	return cmd
+}
@@ -0,0 +1,102 @@
+// Package speech reads words aloud with a text to speech program installed on the system,
+// such as espeak-ng on linux or say on macOS.
+package speech
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Speaker reads text aloud, returning once it's been spoken
+type Speaker interface {
+	Speak(text string) error
+}
+
+// Silent is the Speaker used when there is no text to speech program, it says nothing
+type Silent struct{}
+
+func (Silent) Speak(text string) error {
+	return nil
+}
+
+// Command speaks by running a text to speech program with the text as its last argument
+type Command struct {
+	Path string
+	Args []string
+}
+
+func (c Command) Speak(text string) error {
+	args := append(append([]string{}, c.Args...), text)
+	out, err := exec.Command(c.Path, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running %s: %w %s", filepath.Base(c.Path), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Backend names, besides the programs in backends
+const (
+	Auto = "auto" // the first of backends that's installed
+	None = "none" // don't speak
+)
+
+// backends are the supported programs in the order Auto tries them, with their Spanish voice.
+// endOptions puts -- before the text, so words such as -ito aren't read as options.
+var backends = []struct {
+	name       string
+	voice      string
+	endOptions bool
+}{
+	{"espeak-ng", "es", true},
+	{"espeak", "es", true},
+	{"say", "Monica", false},
+}
+
+// Backends lists the backend names New accepts
+func Backends() []string {
+	names := []string{Auto}
+	for _, b := range backends {
+		names = append(names, b.name)
+	}
+	return append(names, None)
+}
+
+// New returns the Speaker for a backend, speaking with voice or the backend's Spanish voice when
+// voice is empty. Auto falls back to Silent when none of the programs are installed.
+func New(backend string, voice string) (Speaker, error) {
+	if backend == None {
+		return Silent{}, nil
+	}
+	for _, b := range backends {
+		if backend != Auto && backend != b.name {
+			continue
+		}
+		path, err := exec.LookPath(b.name)
+		if err != nil {
+			if backend == Auto {
+				continue
+			}
+			return nil, fmt.Errorf("%s is not installed: %w", b.name, err)
+		}
+		if voice == "" {
+			voice = b.voice
+		}
+		args := []string{"-v", voice}
+		if b.endOptions {
+			args = append(args, "--")
+		}
+		return Command{Path: path, Args: args}, nil
+	}
+	if backend == Auto {
+		return Silent{}, nil
+	}
+	return nil, fmt.Errorf("unknown text to speech backend %q, expected one of %s", backend, strings.Join(Backends(), ", "))
+}
+
+// Enabled reports whether s says anything
+func Enabled(s Speaker) bool {
+	_, silent := s.(Silent)
+	return !silent
+}